@@ -1,11 +1,15 @@
 package mangodex
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"time"
 )
 
 const (
@@ -14,11 +18,36 @@ const (
 
 // DexClient : The MangaDex client.
 type DexClient struct {
-	client *http.Client
-	header http.Header
+	client    *http.Client
+	header    http.Header
+	baseURL   *url.URL
+	reportURL string
+	// configErr : Invalid configuration passed to NewDexClient, returned by every request.
+	configErr error
+	authURL   string
+
+	reportDisabled bool
+	reporter       *reporter
+	pageCache      PageCache
+
+	oauthClientID     string
+	oauthClientSecret string
+	tokenStore        TokenStore
+
+	rateLimiter *rateLimiter
+	retry       *RetryPolicy
 
-	common       service
-	refreshToken string
+	responseCache ResponseCache
+	cacheTTLs     map[string]time.Duration
+
+	middleware []Middleware
+	roundTrip  RoundTripFunc
+	logger     *slog.Logger
+
+	common      service
+	creds       credentials
+	autoRefresh bool
+	refresher   refresher
 
 	// Services for MangaDex API
 	Auth    *AuthService
@@ -34,19 +63,34 @@ type service struct {
 }
 
 // NewDexClient : New anonymous client. To login as an authenticated user, use DexClient.Login.
-func NewDexClient() *DexClient {
-	// Create client
-	client := http.Client{}
-
+// Behaviour of the client can be customised by passing Option values, such as WithBaseURL.
+func NewDexClient(opts ...Option) *DexClient {
 	// Create header
 	header := http.Header{}
 	header.Set("Content-Type", "application/json") // Set default content type.
 
+	// BaseAPI is a constant, valid URL.
+	base, _ := url.Parse(BaseAPI)
+
 	// Create the new client
 	dex := &DexClient{
-		client: &client,
-		header: header,
+		client:      &http.Client{},
+		header:      header,
+		baseURL:     base,
+		reportURL:   MDHomeReportURL,
+		authURL:     OAuthTokenURL,
+		autoRefresh: true,
 	}
+	for _, opt := range opts {
+		opt(dex)
+	}
+	dex.roundTrip = chainMiddleware(func(req *http.Request) (*http.Response, error) {
+		return dex.client.Do(req)
+	}, dex.middleware)
+	if !dex.reportDisabled {
+		dex.reporter = newReporter(dex.client, dex.atHomeHeader(), dex.reportURL)
+	}
+
 	// Set the common client
 	dex.common.client = dex
 
@@ -60,29 +104,150 @@ func NewDexClient() *DexClient {
 	return dex
 }
 
+// apiURL : Resolve a path relative to the base URL of the client.
+func (c *DexClient) apiURL(path string) *url.URL {
+	return c.baseURL.JoinPath(path)
+}
+
+// atHomeHeader : Default headers for requests to MangaDex@Home, without API credentials.
+func (c *DexClient) atHomeHeader() http.Header {
+	header := c.header.Clone()
+	header.Del("Authorization")
+	header.Del("Content-Type")
+	return header
+}
+
+// apiRequest : A request to the API, kept so that it can be sent more than once.
+type apiRequest struct {
+	method string
+	url    string
+	route  string
+	// endpoint : Name of the endpoint, such as "manga.list". Looked up from the route if not set.
+	endpoint string
+	payload  []byte
+	// header : Headers to set in addition to the default headers of the client.
+	header http.Header
+	// anonymous : Send the request without the session token of the client.
+	anonymous bool
+	// attempt : Number of the current attempt, starting from 1.
+	attempt int
+}
+
 // Request : Sends a request to the MangaDex API.
+// Failed requests are retried if the client has a RetryPolicy.
 func (c *DexClient) Request(ctx context.Context, method, url string, body io.Reader) (*http.Response, error) {
+	ar := &apiRequest{
+		method: method,
+		url:    url,
+	}
+
+	// Buffer the body so that it can be replayed when retrying.
+	if body != nil {
+		var err error
+		if ar.payload, err = io.ReadAll(body); err != nil {
+			return nil, err
+		}
+	}
+	return c.do(ctx, ar)
+}
+
+// do : Sends a request, answering it from the response cache if possible.
+func (c *DexClient) do(ctx context.Context, ar *apiRequest) (*http.Response, error) {
+	// Fail every request of a misconfigured client, rather than send it elsewhere.
+	if c.configErr != nil {
+		return nil, c.configErr
+	}
+
+	ar.route = c.route(ar.url)
+	if ar.endpoint == "" {
+		ar.endpoint = routeTemplates[ar.route]
+	}
+	ctx = context.WithValue(ctx, endpointKey{}, ar.endpoint)
+
+	if ttl, ok := c.cacheTTL(ar); ok {
+		return c.doCached(ctx, ar, ttl)
+	}
+	return c.doAttempts(ctx, ar)
+}
+
+// doAttempts : Sends a request, refreshing the session and retrying as required.
+func (c *DexClient) doAttempts(ctx context.Context, ar *apiRequest) (*http.Response, error) {
+	refreshed := false
+	for attempt := 1; ; attempt++ {
+		ar.attempt = attempt
+		session, err := c.sessionFor(ctx, ar)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.send(ctx, ar, session)
+		if err == nil {
+			return resp, nil
+		}
+
+		// Refresh an expired session once, then replay the request.
+		if IsUnauthorized(err) && session != "" && !refreshed && c.canRefresh(ar.route) {
+			refreshed = true
+			if c.refreshSession(ctx, session) == nil {
+				attempt--
+				continue
+			}
+		}
+
+		if c.retry == nil || attempt >= c.retry.MaxAttempts || !c.retry.allows(ar.method) || !retryable(err) {
+			return nil, err
+		}
+		delay := c.retry.backoff(attempt, err)
+		c.logRetry(ctx, ar, delay)
+		if err = sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send : Sends a single attempt of a request, authorized with the session token if set.
+func (c *DexClient) send(ctx context.Context, ar *apiRequest, session string) (*http.Response, error) {
+	var body io.Reader
+	if ar.payload != nil {
+		body = bytes.NewReader(ar.payload)
+	}
+
 	// Create the request
-	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	req, err := http.NewRequestWithContext(ctx, ar.method, ar.url, body)
 	if err != nil {
 		return nil, err
 	}
 
-	// Set header for request.
-	req.Header = c.header
+	// Set header for request. The default headers are cloned, as they are shared between requests.
+	req.Header = c.header.Clone()
+	for key, values := range ar.header {
+		req.Header[key] = values
+	}
+	if session != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", session))
+	}
 
-	// Send request.
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, err
-	} else if resp.StatusCode != 200 {
-		// Decode to an ErrorResponse struct.
-		var er ErrorResponse
-		if err = json.NewDecoder(resp.Body).Decode(&er); err != nil {
+	// Wait for the rate limiter, if any.
+	if c.rateLimiter != nil {
+		if err = c.rateLimiter.wait(ctx, ar.route); err != nil {
 			return nil, err
 		}
+	}
+
+	// Send request through the middleware chain.
+	start := time.Now()
+	resp, err := c.roundTrip(req)
+	c.logRequest(ctx, ar, resp, err, time.Since(start))
+	if err != nil {
+		return nil, err
+	}
+	if c.rateLimiter != nil {
+		c.rateLimiter.update(ar.route, resp)
+	}
+	if resp.StatusCode != 200 {
+		// Decode to an APIError.
 		defer resp.Body.Close()
-		return nil, fmt.Errorf("non-200 status code -> (%d) %s", resp.StatusCode, er.GetErrors())
+		return nil, newAPIError(resp)
 	}
 	return resp, nil
 }
@@ -1,23 +1,24 @@
 package mangodex
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
 	GetMDHomeURLPath = "at-home/server/%s"
 	MDHomeReportURL  = "https://api.mangadex.network/report"
-	dataSaver = "data-saver"
-	force443 = "forcePort443"
+	dataSaver        = "data-saver"
+	force443         = "forcePort443"
 )
 
 // AtHomeService : Provides MangaDex@Home services provided by the API.
@@ -41,15 +42,53 @@ type ChaptersData struct {
 	DataSaver []string `json:"dataSaver"`
 }
 
+// ErrChapterChanged : Returned when a chapter was re-uploaded while its pages were being fetched.
+var ErrChapterChanged = errors.New("chapter was changed on MangaDex@Home")
+
+// Default failover settings of MDHomeClient.
+const (
+	DefaultFailoverThreshold = 2
+	DefaultMaxPageAttempts   = 3
+)
+
 // MDHomeClient : Client for interfacing with MangaDex@Home.
 type MDHomeClient struct {
-	client  *http.Client
-	baseURL string
-	quality string
-	hash    string
-	report  *reportPayload
-	start    time.Time
-	Pages   []string
+	service      *AtHomeService
+	client       *http.Client
+	header       http.Header
+	reporter     *reporter
+	cache        PageCache
+	logger       *slog.Logger
+	chapterID    string
+	forcePort443 bool
+	quality      string
+	hash         string
+	data         []string
+	dataSaver    []string
+	Pages        []string
+
+	// FailoverThreshold : Number of consecutive failed fetches after which a new server is requested.
+	FailoverThreshold int
+	// MaxPageAttempts : Number of times fetching a page is attempted before giving up.
+	MaxPageAttempts int
+	// FallbackToDataSaver : Use the data-saver version of a page for the last attempt if the original keeps failing.
+	FallbackToDataSaver bool
+	// VerifyPages : Check pages against the hash in their filename. Mismatching pages count as failed fetches,
+	// and are attempted again on a new server.
+	VerifyPages bool
+
+	mu        sync.Mutex
+	refreshMu sync.Mutex
+	baseURL   string
+	failures  int
+	served    map[string]PageSource
+}
+
+// PageSource : Where a page was fetched from.
+type PageSource struct {
+	BaseURL  string
+	Quality  string
+	Filename string
 }
 
 // NewMDHomeClient : Get MangaDex@Home client for a chapter.
@@ -60,16 +99,7 @@ func (s *AtHomeService) NewMDHomeClient(chapterID string, quality string, forceP
 
 // NewMDHomeClientContext : NewMDHomeClient with custom context.
 func (s *AtHomeService) NewMDHomeClientContext(ctx context.Context, chapterID string, quality string, forcePort443 bool) (*MDHomeClient, error) {
-	u, _ := url.Parse(BaseAPI)
-	u.Path = fmt.Sprintf(GetMDHomeURLPath, chapterID)
-
-	// Set query parameters
-	q := u.Query()
-	q.Set(force443, strconv.FormatBool(forcePort443))
-	u.RawQuery = q.Encode()
-
-	var r MDHomeServerResponse
-	err := s.client.RequestAndDecode(ctx, http.MethodGet, u.String(), nil, &r)
+	r, err := s.getServer(ctx, chapterID, forcePort443)
 	if err != nil {
 		return nil, err
 	}
@@ -81,78 +111,239 @@ func (s *AtHomeService) NewMDHomeClientContext(ctx context.Context, chapterID st
 	}
 
 	return &MDHomeClient{
-		client:  &http.Client{},
-		baseURL: r.BaseURL,
-		quality: quality,
-		hash:    r.Chapter.Hash,
-		Pages:   pages,
+		service:           s,
+		client:            s.client.client,
+		header:            s.client.atHomeHeader(),
+		reporter:          s.client.reporter,
+		cache:             s.client.pageCache,
+		logger:            s.client.logger,
+		chapterID:         chapterID,
+		forcePort443:      forcePort443,
+		baseURL:           r.BaseURL,
+		quality:           quality,
+		hash:              r.Chapter.Hash,
+		data:              r.Chapter.Data,
+		dataSaver:         r.Chapter.DataSaver,
+		Pages:             pages,
+		FailoverThreshold: DefaultFailoverThreshold,
+		MaxPageAttempts:   DefaultMaxPageAttempts,
+		served:            map[string]PageSource{},
 	}, nil
 }
 
+// getServer : Request a MangaDex@Home server for a chapter.
+func (s *AtHomeService) getServer(ctx context.Context, chapterID string, forcePort443 bool) (*MDHomeServerResponse, error) {
+	u := s.client.apiURL(fmt.Sprintf(GetMDHomeURLPath, chapterID))
+
+	// Set query parameters
+	q := u.Query()
+	q.Set(force443, strconv.FormatBool(forcePort443))
+	u.RawQuery = q.Encode()
+
+	var r MDHomeServerResponse
+	err := s.client.RequestAndDecode(ctx, http.MethodGet, u.String(), nil, &r)
+	return &r, err
+}
+
 // GetChapterPage : Return page data for a chapter with the filename of that page.
 func (c *MDHomeClient) GetChapterPage(filename string) ([]byte, error) {
 	return c.GetChapterPageWithContext(context.Background(), filename)
 }
 
 // GetChapterPageWithContext : GetChapterPage with custom context.
+// Failed fetches are attempted again, on a new server once the current one has failed FailoverThreshold times.
 func (c *MDHomeClient) GetChapterPageWithContext(ctx context.Context, filename string) (fileData []byte, err error) {
-	// Send report in the background.
-	defer func() { go c.reportContext(ctx) }()
+	if data, ok := c.cached(filename); ok {
+		c.logPageCacheHit(ctx, filename)
+		return data, nil
+	}
+	err = c.withFailover(ctx, filename, func(src PageSource) error {
+		ps, err := c.openPage(ctx, src)
+		if err != nil {
+			return err
+		}
+		defer ps.Close()
 
-	path := strings.Join([]string{c.baseURL, c.quality, c.hash, filename}, "/")
-	c.report = newPayload(path)
+		fileData, err = io.ReadAll(ps)
+		return err
+	})
+	return fileData, err
+}
+
+// GetChapterPageStream : Open a page for streaming instead of reading it into memory. The stream must be closed,
+// which reports the result to MangaDex@Home. Failover only applies to opening the stream.
+// Pages found in the PageCache of the client are streamed from memory, with an empty Source.BaseURL.
+func (c *MDHomeClient) GetChapterPageStream(ctx context.Context, filename string) (ps *PageStream, err error) {
+	if data, ok := c.cached(filename); ok {
+		c.logPageCacheHit(ctx, filename)
+		src := PageSource{Quality: c.quality, Filename: filename}
+		return newCachedPageStream(src, data), nil
+	}
+	err = c.withFailover(ctx, filename, func(src PageSource) error {
+		ps, err = c.openPage(ctx, src)
+		return err
+	})
+	return ps, err
+}
+
+// withFailover : Call fetch with the source of a page until it succeeds or MaxPageAttempts is reached.
+func (c *MDHomeClient) withFailover(ctx context.Context, filename string, fetch func(src PageSource) error) error {
+	src := PageSource{Quality: c.quality, Filename: filename}
+	for attempt := 1; ; attempt++ {
+		src.BaseURL = c.node()
+		start := time.Now()
+		err := fetch(src)
+		c.logPage(ctx, src, attempt, err, time.Since(start))
+		if err == nil {
+			c.recordSuccess(filename, src)
+			return nil
+		}
+		if ctx.Err() != nil || attempt >= max(c.MaxPageAttempts, 1) {
+			return err
+		}
+
+		// A corrupt page is not fetched from the same server again.
+		corrupt := errors.Is(err, ErrPageHashMismatch)
+		if rerr := c.recordFailure(ctx, src.BaseURL, corrupt); rerr != nil {
+			return errors.Join(err, rerr)
+		}
+
+		// Fall back to the data-saver version of the page for the last attempt.
+		if c.FallbackToDataSaver && src.Quality != dataSaver && attempt+1 == c.MaxPageAttempts {
+			if alt, ok := c.dataSaverPage(filename); ok {
+				src.Quality, src.Filename = dataSaver, alt
+			}
+		}
+	}
+}
+
+// openPage : Start fetching a page from a server.
+func (c *MDHomeClient) openPage(ctx context.Context, src PageSource) (*PageStream, error) {
+	path := strings.Join([]string{src.BaseURL, src.Quality, c.hash, src.Filename}, "/")
+	ps := &PageStream{
+		Source:   src,
+		reporter: c.reporter,
+		report:   newPayload(path),
+		// time will be slightly inflated, but w/e
+		start: time.Now(),
+	}
 
-	// time will be slightly inflated, but w/e
-	c.start = time.Now()
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
 	if err != nil {
-		return
+		return nil, err
 	}
+	req.Header = c.header.Clone()
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return
+		ps.sendReport()
+		return nil, err
+	}
+	ps.report.Cached = strings.HasPrefix(resp.Header.Get("X-Cache"), "HIT")
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		ps.sendReport()
+		return nil, newAPIError(resp)
 	}
-	defer resp.Body.Close()
 
-	// Read file data.
-	fileData, err = io.ReadAll(resp.Body)
-	c.report.Bytes = len(fileData)
-	c.report.Success = err == nil && resp.StatusCode == http.StatusOK 
-	c.report.Cached = strings.HasPrefix(resp.Header.Get("X-Cache"), "HIT")
-	return
+	ps.body = resp.Body
+	if c.VerifyPages {
+		ps.verify()
+	}
+	if c.cache != nil {
+		ps.cache = newPageWriter(c.cache, PageKey{Hash: c.hash, Quality: src.Quality, Filename: src.Filename})
+	}
+	ps.ContentType = resp.Header.Get("Content-Type")
+	ps.ContentLength = resp.ContentLength
+	return ps, nil
 }
 
-func (c *MDHomeClient) FileUrl(filename string) string {
-	return strings.Join([]string{c.baseURL, c.quality, c.hash, filename}, "/")
+// cached : Get a page from the PageCache of the client. With VerifyPages set, cached pages that do not
+// match their hash are ignored.
+func (c *MDHomeClient) cached(filename string) ([]byte, bool) {
+	if c.cache == nil {
+		return nil, false
+	}
+	data, ok := c.cache.Get(PageKey{Hash: c.hash, Quality: c.quality, Filename: filename})
+	if !ok || (c.VerifyPages && errors.Is(VerifyPage(filename, data), ErrPageHashMismatch)) {
+		return nil, false
+	}
+	return data, true
 }
 
-// reportPayload : Required fields for reporting page download result.
-type reportPayload struct {
-	URL      string
-	Success  bool
-	Bytes    int
-	Duration int64
-	Cached   bool
+// node : Get the base URL of the current server.
+func (c *MDHomeClient) node() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.baseURL
 }
 
-func newPayload(path string) *reportPayload {
-	return &reportPayload{
-		URL:     path,
+// recordSuccess : Reset the failure count and remember where a page was fetched from.
+func (c *MDHomeClient) recordSuccess(filename string, src PageSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures = 0
+	c.served[filename] = src
+}
+
+// recordFailure : Count a failed fetch from baseURL, requesting a new server once the threshold is reached
+// or straight away if force is set.
+func (c *MDHomeClient) recordFailure(ctx context.Context, baseURL string, force bool) error {
+	c.mu.Lock()
+	if c.baseURL == baseURL {
+		c.failures++
 	}
+	failed := c.baseURL == baseURL && (force || c.failures >= max(c.FailoverThreshold, 1))
+	c.mu.Unlock()
+	if !failed {
+		return nil
+	}
+	return c.refreshNode(ctx, baseURL)
 }
 
-// reportContext : Report success of getting chapter page data.
-func (c *MDHomeClient) reportContext(ctx context.Context) {
-	c.report.Duration = time.Since(c.start).Milliseconds()
-	rBytes, err := json.Marshal(c.report)
-	if err != nil {
-		return
+// refreshNode : Request a new server to replace the failing one at baseURL.
+func (c *MDHomeClient) refreshNode(ctx context.Context, baseURL string) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	// Another fetch may already have replaced the server.
+	if c.node() != baseURL {
+		return nil
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, MDHomeReportURL, bytes.NewBuffer(rBytes))
+	r, err := c.service.getServer(ctx, c.chapterID, c.forcePort443)
 	if err != nil {
-		return
+		return err
+	}
+	if r.Chapter.Hash != c.hash {
+		return ErrChapterChanged
 	}
-	c.client.Do(req)
+
+	c.logNodeChange(ctx, baseURL, r.BaseURL)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.baseURL = r.BaseURL
+	c.failures = 0
+	return nil
+}
+
+// dataSaverPage : Get the data-saver filename of a page.
+func (c *MDHomeClient) dataSaverPage(filename string) (string, bool) {
+	i := slices.Index(c.data, filename)
+	if i < 0 || i >= len(c.dataSaver) {
+		return "", false
+	}
+	return c.dataSaver[i], true
+}
+
+// ServedBy : Get where a page was last fetched from successfully.
+func (c *MDHomeClient) ServedBy(filename string) (PageSource, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	src, ok := c.served[filename]
+	return src, ok
+}
+
+func (c *MDHomeClient) FileUrl(filename string) string {
+	return strings.Join([]string{c.node(), c.quality, c.hash, filename}, "/")
 }
@@ -0,0 +1,437 @@
+package mangodex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeAtHome : Fake of the at-home server endpoint, a MangaDex@Home node and the report endpoint.
+type fakeAtHome struct {
+	srv   *httptest.Server
+	pages map[string][]byte
+
+	mu      sync.Mutex
+	servers int
+	broken  map[string]bool
+	corrupt string
+	reports []map[string]any
+}
+
+func newFakeAtHome(t *testing.T, pages int) (*fakeAtHome, *DexClient) {
+	t.Helper()
+	f := &fakeAtHome{pages: map[string][]byte{}, broken: map[string]bool{}}
+	for i := 0; i < pages; i++ {
+		f.pages[fmt.Sprintf("p%d-abc.png", i)] = []byte(fmt.Sprintf("page %d", i))
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/at-home/server/{id}", func(w http.ResponseWriter, r *http.Request) {
+		data := make([]string, pages)
+		saver := make([]string, pages)
+		for i := range data {
+			data[i] = fmt.Sprintf("p%d-abc.png", i)
+			saver[i] = fmt.Sprintf("s%d-abc.jpg", i)
+		}
+		f.mu.Lock()
+		node := fmt.Sprintf("/node%d", f.servers)
+		f.servers++
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(&MDHomeServerResponse{
+			Result:  "ok",
+			BaseURL: f.srv.URL + node,
+			Chapter: ChaptersData{Hash: "hash", Data: data, DataSaver: saver},
+		})
+	})
+	mux.HandleFunc("/{node}/{quality}/hash/{file}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		broken := f.broken[r.PathValue("node")] || f.broken[r.PathValue("quality")]
+		corrupt := f.corrupt == r.PathValue("node")
+		f.mu.Unlock()
+		if broken {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		file := r.PathValue("file")
+		if r.PathValue("quality") == dataSaver {
+			file = "p" + strings.TrimPrefix(strings.TrimSuffix(file, ".jpg")+".png", "s")
+		}
+		if corrupt {
+			file = "corrupt"
+		}
+		page, ok := f.pages[file]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(page)
+	})
+	mux.HandleFunc("/manga/{id}/aggregate", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":"ok","volumes":{"1":{"volume":"1","count":2,"chapters":{
+			"2":{"id":"c2","chapter":"2","count":1},
+			"1":{"id":"c1","chapter":"1","count":1},
+			"3":{"id":"c3-ext","chapter":"3","count":1}
+		}}}}`))
+	})
+	mux.HandleFunc("/manga/{id}/feed", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":"ok","total":5,"data":[
+			{"id":"c1-a","attributes":{"volume":"1","chapter":"1","translatedLanguage":"en","publishAt":"2021-01-02T00:00:00+00:00"},
+				"relationships":[{"id":"g-other","type":"scanlation_group"}]},
+			{"id":"c1-b","attributes":{"volume":"1","chapter":"1","translatedLanguage":"en","publishAt":"2021-01-01T00:00:00+00:00"},
+				"relationships":[{"id":"g-preferred","type":"scanlation_group"}]},
+			{"id":"c2","attributes":{"volume":"1","chapter":"2","translatedLanguage":"en"}},
+			{"id":"c3","attributes":{"volume":"2","chapter":"3","translatedLanguage":"en"}},
+			{"id":"c4","attributes":{"volume":"1","chapter":"4","translatedLanguage":"en","externalUrl":"https://example.com"}}
+		]}`))
+	})
+	mux.HandleFunc("/chapter/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		external := "null"
+		if strings.HasSuffix(id, "-ext") {
+			external = `"https://example.com"`
+		}
+		fmt.Fprintf(w, `{"result":"ok","data":{"id":%q,"type":"chapter","attributes":{"title":"Title %s","volume":"1","chapter":%q,"translatedLanguage":"en","externalUrl":%s}}}`,
+			id, id, strings.TrimSuffix(strings.TrimPrefix(id, "c"), "-ext"), external)
+	})
+	mux.HandleFunc("/report", func(w http.ResponseWriter, r *http.Request) {
+		var report map[string]any
+		json.NewDecoder(r.Body).Decode(&report)
+		f.mu.Lock()
+		f.reports = append(f.reports, report)
+		f.mu.Unlock()
+	})
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f, NewDexClient(WithBaseURL(f.srv.URL), WithReportURL(f.srv.URL+"/report"))
+}
+
+func TestDownloadToDir(t *testing.T) {
+	f, c := newFakeAtHome(t, 12)
+	mc, err := c.AtHome.NewMDHomeClient("chapter", "data", false)
+	if err != nil {
+		t.Fatalf("new client: %s", err)
+	}
+
+	d := NewDownloader(mc)
+	d.Concurrency = 3
+	var calls int
+	d.OnProgress = func(p DownloadProgress) {
+		calls++
+		if p.Done != calls || p.Total != 12 {
+			t.Errorf("unexpected progress %+v", p)
+		}
+	}
+
+	dir := t.TempDir()
+	results, err := d.DownloadToDir(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("download: %s", err)
+	}
+	if calls != 12 {
+		t.Errorf("expected 12 progress calls, got %d", calls)
+	}
+	for i, r := range results {
+		want := fmt.Sprintf("%02d.png", i+1)
+		if r.Name != want {
+			t.Errorf("expected name %s, got %s", want, r.Name)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, want))
+		if err != nil || string(data) != fmt.Sprintf("page %d", i) {
+			t.Errorf("unexpected content for %s: %q, %v", want, data, err)
+		}
+	}
+
+	// Missing pages are reported per page.
+	d.OnProgress = nil
+	mc.Pages = append(mc.Pages, "missing.png")
+	results, err = d.DownloadToDir(context.Background(), dir)
+	if err == nil || !strings.Contains(err.Error(), "1 of 13 pages failed") || !IsNotFound(results[12].Err) {
+		t.Errorf("expected missing page to fail, got %v", err)
+	}
+
+	// Pages are streamed to disk, and only kept once they were read completely and verified.
+	corrupt := "x1-" + strings.Repeat("0", 64) + ".png"
+	f.pages[corrupt] = []byte("corrupt")
+	mc.Pages, mc.VerifyPages = []string{corrupt}, true
+	dir = t.TempDir()
+	results, err = d.DownloadToDir(context.Background(), dir)
+	if !errors.Is(err, ErrPageHashMismatch) || results[0].Bytes != 7 {
+		t.Errorf("expected corrupt page to fail, got %+v, %v", results[0], err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("expected no files for the corrupt page, got %v", files)
+	}
+}
+
+func TestMDHomeFailover(t *testing.T) {
+	f, c := newFakeAtHome(t, 3)
+	mc, err := c.AtHome.NewMDHomeClient("chapter", "data", false)
+	if err != nil {
+		t.Fatalf("new client: %s", err)
+	}
+
+	// The first node dies, so a new one is requested after two failures.
+	f.broken["node0"] = true
+	data, err := mc.GetChapterPage(mc.Pages[0])
+	if err != nil || string(data) != "page 0" {
+		t.Fatalf("expected page from new node, got %q, %v", data, err)
+	}
+	if src, _ := mc.ServedBy(mc.Pages[0]); src.BaseURL != f.srv.URL+"/node1" || src.Quality != "data" {
+		t.Errorf("unexpected page source %+v", src)
+	}
+
+	// Original quality is unavailable everywhere, so the data-saver page is used.
+	f.broken["data"] = true
+	if _, err = mc.GetChapterPage(mc.Pages[1]); err == nil {
+		t.Fatal("expected page to fail without data-saver fallback")
+	}
+	mc.FallbackToDataSaver = true
+	data, err = mc.GetChapterPage(mc.Pages[1])
+	if err != nil || string(data) != "page 1" {
+		t.Fatalf("expected data-saver page, got %q, %v", data, err)
+	}
+	if src, _ := mc.ServedBy(mc.Pages[1]); src.Quality != dataSaver || src.Filename != "s1-abc.jpg" {
+		t.Errorf("unexpected page source %+v", src)
+	}
+}
+
+func TestAtHomeReports(t *testing.T) {
+	f, c := newFakeAtHome(t, 2)
+	mc, err := c.AtHome.NewMDHomeClient("chapter", "data", false)
+	if err != nil {
+		t.Fatalf("new client: %s", err)
+	}
+
+	// Reports are still sent when the context of the fetch is cancelled straight after.
+	ctx, cancel := context.WithCancel(context.Background())
+	for _, page := range mc.Pages {
+		if _, err = mc.GetChapterPageWithContext(ctx, page); err != nil {
+			t.Fatalf("get page: %s", err)
+		}
+	}
+	cancel()
+	if err = c.Close(); err != nil {
+		t.Fatalf("close: %s", err)
+	}
+
+	if len(f.reports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(f.reports))
+	}
+	for _, r := range f.reports {
+		if !strings.HasPrefix(r["url"].(string), f.srv.URL+"/node0/data/hash/") || r["success"] != true || r["bytes"] != 6.0 {
+			t.Errorf("unexpected report %v", r)
+		}
+	}
+
+	// No reports are sent after closing, or for mangadex.org hosts.
+	mc.GetChapterPage(mc.Pages[0])
+	if reportable("https://uploads.mangadex.org/data/hash/file.png") {
+		t.Error("expected mangadex.org pages not to be reported")
+	}
+	if len(f.reports) != 2 {
+		t.Errorf("expected no reports after close, got %d", len(f.reports))
+	}
+}
+
+func TestGetChapterPageStream(t *testing.T) {
+	f, c := newFakeAtHome(t, 2)
+	mc, err := c.AtHome.NewMDHomeClient("chapter", "data", false)
+	if err != nil {
+		t.Fatalf("new client: %s", err)
+	}
+
+	ps, err := mc.GetChapterPageStream(context.Background(), mc.Pages[1])
+	if err != nil {
+		t.Fatalf("open stream: %s", err)
+	}
+	if ps.ContentType != "image/png" || ps.ContentLength != 6 {
+		t.Errorf("unexpected metadata %q, %d", ps.ContentType, ps.ContentLength)
+	}
+	var sb strings.Builder
+	if _, err = io.Copy(&sb, ps); err != nil || sb.String() != "page 1" {
+		t.Errorf("unexpected stream content %q, %v", sb.String(), err)
+	}
+	ps.Close()
+	ps.Close()
+
+	// A stream closed before the end is reported as failed.
+	ps, err = mc.GetChapterPageStream(context.Background(), mc.Pages[0])
+	if err != nil {
+		t.Fatalf("open stream: %s", err)
+	}
+	ps.Read(make([]byte, 2))
+	ps.Close()
+
+	c.Close()
+	if len(f.reports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(f.reports))
+	}
+	if r := f.reports[0]; r["success"] != true || r["bytes"] != 6.0 {
+		t.Errorf("unexpected report for complete stream %v", r)
+	}
+	if r := f.reports[1]; r["success"] != false || r["bytes"] != 2.0 {
+		t.Errorf("unexpected report for aborted stream %v", r)
+	}
+}
+
+func TestVerifyPage(t *testing.T) {
+	data := []byte("page data")
+	sum := sha256.Sum256(data)
+	full := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		filename string
+		want     error
+	}{
+		{"x1-" + full + ".png", nil},
+		{"1-" + full[:16] + ".jpg", nil},
+		{"x1-" + strings.Repeat("0", 64) + ".png", ErrPageHashMismatch},
+		{"cover.png", ErrNoPageHash},
+	}
+	for _, tt := range tests {
+		if err := VerifyPage(tt.filename, data); !errors.Is(err, tt.want) {
+			t.Errorf("VerifyPage(%q) = %v, want %v", tt.filename, err, tt.want)
+		}
+	}
+}
+
+func TestMDHomeVerifyPages(t *testing.T) {
+	f, c := newFakeAtHome(t, 1)
+	mc, err := c.AtHome.NewMDHomeClient("chapter", "data", false)
+	if err != nil {
+		t.Fatalf("new client: %s", err)
+	}
+
+	// Serve the page under a filename matching its content, and corrupt it on the first node.
+	sum := sha256.Sum256([]byte("page 0"))
+	page := "p0-" + hex.EncodeToString(sum[:]) + ".png"
+	f.pages[page] = []byte("page 0")
+	f.pages["corrupt"] = []byte("corrupt")
+	mc.VerifyPages = true
+
+	f.mu.Lock()
+	f.corrupt = "node0"
+	f.mu.Unlock()
+	data, err := mc.GetChapterPage(page)
+	if err != nil || string(data) != "page 0" {
+		t.Fatalf("expected verified page, got %q, %v", data, err)
+	}
+	if src, _ := mc.ServedBy(page); src.BaseURL != f.srv.URL+"/node1" {
+		t.Errorf("expected page from a new node, got %+v", src)
+	}
+
+	c.Close()
+	if len(f.reports) != 2 || f.reports[0]["success"] != false || f.reports[1]["success"] != true {
+		t.Errorf("unexpected reports %v", f.reports)
+	}
+}
+
+func TestResumeDownload(t *testing.T) {
+	_, c := newFakeAtHome(t, 4)
+	mc, err := c.AtHome.NewMDHomeClient("chapter", "data", false)
+	if err != nil {
+		t.Fatalf("new client: %s", err)
+	}
+	dir := t.TempDir()
+	d := NewDownloader(mc)
+	if _, err = d.DownloadToDir(context.Background(), dir); err != nil {
+		t.Fatalf("download: %s", err)
+	}
+
+	m, err := LoadManifest(dir)
+	if err != nil || m == nil || m.ChapterID != "chapter" || m.Hash != "hash" || len(m.Pages) != 4 {
+		t.Fatalf("unexpected manifest %+v, %v", m, err)
+	}
+
+	// Only missing and damaged pages are fetched again.
+	os.Remove(filepath.Join(dir, "2.png"))
+	os.WriteFile(filepath.Join(dir, "3.png"), []byte("damaged"), 0o644)
+	results, err := d.DownloadToDir(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("resume download: %s", err)
+	}
+	for i, r := range results {
+		if wantSkipped := i == 0 || i == 3; r.Skipped != wantSkipped {
+			t.Errorf("page %d skipped = %v, want %v", i, r.Skipped, wantSkipped)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "3.png")); string(data) != "page 2" {
+		t.Errorf("damaged page not replaced, got %q", data)
+	}
+
+	// A re-uploaded chapter is downloaded again completely.
+	m.Hash = "old"
+	data, _ := json.Marshal(m)
+	os.WriteFile(filepath.Join(dir, ManifestName), data, 0o644)
+	if results, err = d.DownloadToDir(context.Background(), dir); err != nil {
+		t.Fatalf("download re-upload: %s", err)
+	}
+	for i, r := range results {
+		if r.Skipped {
+			t.Errorf("page %d of re-uploaded chapter skipped", i)
+		}
+	}
+	if m, _ = LoadManifest(dir); m.Hash != "hash" {
+		t.Errorf("manifest hash not updated, got %q", m.Hash)
+	}
+
+	// Only pages in the download directory are removed, whatever names the manifest contains.
+	outside := filepath.Join(t.TempDir(), "keep.png")
+	os.WriteFile(outside, []byte("keep"), 0o644)
+	rel, _ := filepath.Rel(dir, outside)
+	m.Hash = "old"
+	m.Pages["traversal"] = ManifestPage{Name: rel}
+	m.Pages["absolute"] = ManifestPage{Name: outside}
+	data, _ = json.Marshal(m)
+	os.WriteFile(filepath.Join(dir, ManifestName), data, 0o644)
+	if _, err = d.DownloadToDir(context.Background(), dir); err != nil {
+		t.Fatalf("download re-upload: %s", err)
+	}
+	if _, err = os.Stat(outside); err != nil {
+		t.Errorf("file outside the download directory was removed: %s", err)
+	}
+}
+
+func TestSeriesDownloader(t *testing.T) {
+	_, c := newFakeAtHome(t, 2)
+	d := NewSeriesDownloader(c)
+	d.PreferredGroups = []string{"g-preferred"}
+	d.Volumes = &Range{Min: 1, Max: 1}
+
+	var events []SeriesEventType
+	d.OnEvent = func(e SeriesEvent) {
+		events = append(events, e.Type)
+	}
+
+	dir := t.TempDir()
+	results, err := d.Download(context.Background(), "manga", dir)
+	if err != nil {
+		t.Fatalf("download series: %s", err)
+	}
+	if len(results) != 2 || results[0].Chapter.ID != "c1-b" || results[1].Chapter.ID != "c2" {
+		t.Fatalf("unexpected chapters %+v", results)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "en_vol1_ch2", "1.png")); err != nil {
+		t.Errorf("chapter not downloaded: %s", err)
+	}
+
+	counts := map[SeriesEventType]int{}
+	for _, e := range events {
+		counts[e]++
+	}
+	if events[0] != ChaptersResolved || counts[ChapterStarted] != 2 || counts[PageDownloaded] != 4 || counts[ChapterFinished] != 2 {
+		t.Errorf("unexpected events %v", events)
+	}
+}
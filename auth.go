@@ -6,12 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"net/url"
+	"slices"
 )
 
 /*
-	ALL LOGIN/LOGOUT FUNCTIONS ARE CURRENTLY DEPRECATED
-	ONLY USE PERMISSIONS 
+	ALL LOGIN/LOGOUT FUNCTIONS BELOW ARE CURRENTLY DEPRECATED
+	USE PasswordLogin WITH A PERSONAL API CLIENT INSTEAD, SEE oauth.go
 */
 
 const (
@@ -38,17 +38,52 @@ func (ar AuthResponse) GetResult() string {
 	return ar.Result
 }
 
-func (s *AuthService) CheckPermissions(token string) error {
+// AuthCheckResponse : Response for checking the authentication and permissions of a token.
+type AuthCheckResponse struct {
+	AuthResponse
+}
+
+// HasPermission : Check whether the token was granted a permission, such as "manga.edit".
+func (r *AuthCheckResponse) HasPermission(name string) bool {
+	return slices.Contains(r.Permissions, name)
+}
+
+// HasRole : Check whether the user of the token has a role, such as "ROLE_ADMIN".
+func (r *AuthCheckResponse) HasRole(role string) bool {
+	return slices.Contains(r.Roles, role)
+}
+
+// CheckPermissions : Check the roles and permissions of a session token.
+// If token is empty, the session token of the client is checked.
+// https://api.mangadex.org/docs/redoc.html#tag/Authentication/operation/get-auth-check
+func (s *AuthService) CheckPermissions(token string) (*AuthCheckResponse, error) {
 	return s.CheckPermissionsWithContext(context.Background(), token)
 }
 
-func (s *AuthService) CheckPermissionsWithContext(ctx context.Context, token string) (err error) {
-	u, _ := url.Parse(BaseAPI)
-	u.Path = PermissionPath
-	s.client.header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+// CheckPermissionsWithContext : CheckPermissions with custom context.
+func (s *AuthService) CheckPermissionsWithContext(ctx context.Context, token string) (*AuthCheckResponse, error) {
+	u := s.client.apiURL(PermissionPath)
+
+	ar := &apiRequest{
+		method: http.MethodGet,
+		url:    u.String(),
+	}
+	// Send the given token for this request only, without touching the tokens of the client.
+	if token != "" {
+		ar.header = http.Header{}
+		ar.header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		ar.anonymous = true
+	}
 
-	// maybe this isn't a thing yet? says deprecated on the site
-	return
+	resp, err := s.client.do(ctx, ar)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var r AuthCheckResponse
+	err = json.NewDecoder(resp.Body).Decode(&r)
+	return &r, err
 }
 
 // token : MangaDex token. Includes session and refresh token.
@@ -65,8 +100,7 @@ func (s *AuthService) Login(user, pwd string) error {
 
 // LoginContext : Login with custom context.
 func (s *AuthService) LoginContext(ctx context.Context, user, pwd string) error {
-	u, _ := url.Parse(BaseAPI)
-	u.Path = LoginPath
+	u := s.client.apiURL(LoginPath)
 
 	// Create required request body.
 	req := map[string]string{
@@ -83,10 +117,10 @@ func (s *AuthService) LoginContext(ctx context.Context, user, pwd string) error
 		return err
 	}
 
-	// Set client token and header for authorization.
-	s.SetRefreshToken(ar.Token.Refresh)
-	s.client.header.Set("Authorization", fmt.Sprintf("Bearer %s", ar.Token.Session))
-	return nil
+	// Set client tokens used for authorization.
+	s.client.creds.set(ar.Token.Session, ar.Token.Refresh)
+	s.client.userChanged(ctx)
+	return s.client.persistTokens()
 }
 
 // Logout : Logout of MangaDex and invalidates all tokens.
@@ -97,18 +131,17 @@ func (s *AuthService) Logout() error {
 
 // LogoutContext : Logout with custom context.
 func (s *AuthService) LogoutContext(ctx context.Context) error {
-	u, _ := url.Parse(BaseAPI)
-	u.Path = LogoutPath
+	u := s.client.apiURL(LogoutPath)
 
 	var r Response
 	if err := s.client.RequestAndDecode(ctx, http.MethodPost, u.String(), nil, &r); err != nil {
 		return err
 	}
 
-	// Remove the stored client token and also authorization header.
-	s.SetRefreshToken("")
-	s.client.header.Del("Authorization")
-	return nil
+	// Remove the stored client tokens.
+	s.client.creds.set("", "")
+	s.client.userChanged(ctx)
+	return s.client.persistTokens()
 }
 
 // RefreshSessionToken : Refresh session token using refresh token.
@@ -119,12 +152,12 @@ func (s *AuthService) RefreshSessionToken() error {
 
 // RefreshSessionTokenContext : refreshToken with custom context.
 func (s *AuthService) RefreshSessionTokenContext(ctx context.Context) error {
-	u, _ := url.Parse(BaseAPI)
-	u.Path = RefreshTokenPath
+	u := s.client.apiURL(RefreshTokenPath)
 
 	// Create required request body.
+	_, refresh := s.client.creds.get()
 	req := map[string]string{
-		"token": s.client.refreshToken,
+		"token": refresh,
 	}
 	rBytes, err := json.Marshal(&req)
 	if err != nil {
@@ -137,22 +170,25 @@ func (s *AuthService) RefreshSessionTokenContext(ctx context.Context) error {
 	}
 
 	// Update tokens
-	s.SetRefreshToken(ar.Token.Refresh)
-	s.client.header.Set("Authorization", fmt.Sprintf("Bearer %s", ar.Token.Session))
+	s.client.creds.set(ar.Token.Session, ar.Token.Refresh)
+	s.client.saveRefreshedTokens(ctx)
 	return nil
 }
 
 // IsLoggedIn : Return true when client logged in and false otherwise.
 func (s *AuthService) IsLoggedIn() bool {
-	return s.client.header.Get("Authorization") != ""
+	session, _ := s.client.creds.get()
+	return session != ""
 }
 
 // GetRefreshToken : Get the current refresh token of the client.
 func (s *AuthService) GetRefreshToken() string {
-	return s.client.refreshToken
+	_, refresh := s.client.creds.get()
+	return refresh
 }
 
 // SetRefreshToken : Set the refresh token for the client.
 func (s *AuthService) SetRefreshToken(refreshToken string) {
-	s.client.refreshToken = refreshToken
+	s.client.creds.setRefresh(refreshToken)
+	s.client.userChanged(context.Background())
 }
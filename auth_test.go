@@ -0,0 +1,298 @@
+package mangodex
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeAuthAPI : Fake of the API that hands out numbered session tokens on login and refresh.
+type fakeAuthAPI struct {
+	issued atomic.Int64
+	seen   sync.Map
+}
+
+func (f *fakeAuthAPI) token() string {
+	return fmt.Sprintf("session-%d", f.issued.Add(1))
+}
+
+func (f *fakeAuthAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/" + LoginPath, "/" + RefreshTokenPath:
+		json.NewEncoder(w).Encode(map[string]any{
+			"result": "ok",
+			"token":  map[string]string{"session": f.token(), "refresh": "refresh"},
+		})
+	default:
+		f.seen.Store(r.Header.Get("Authorization"), true)
+		w.Write([]byte(`{"result":"ok","data":[]}`))
+	}
+}
+
+func TestConcurrentRequestsDuringRefresh(t *testing.T) {
+	api := &fakeAuthAPI{}
+	c := newTestClient(t, api)
+	if err := c.Auth.Login("user", "password"); err != nil {
+		t.Fatalf("login: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if _, err := c.Manga.GetMangaList(&ListMangaParams{Title: "test"}); err != nil {
+					t.Errorf("search: %s", err)
+				}
+				c.Auth.IsLoggedIn()
+			}
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		if err := c.Auth.RefreshSessionToken(); err != nil {
+			t.Errorf("refresh: %s", err)
+		}
+	}
+	wg.Wait()
+
+	api.seen.Range(func(key, _ any) bool {
+		if !strings.HasPrefix(key.(string), "Bearer session-") {
+			t.Errorf("unexpected Authorization header %q", key)
+		}
+		return true
+	})
+	if c.header.Get("Authorization") != "" {
+		t.Error("default headers were modified")
+	}
+}
+
+// fakeJWT : Build an unsigned JWT expiring at exp.
+func fakeJWT(exp time.Time) string {
+	payload, _ := json.Marshal(map[string]int64{"exp": exp.Unix()})
+	return "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+}
+
+func TestRefreshOnUnauthorized(t *testing.T) {
+	var refreshes atomic.Int64
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/"+RefreshTokenPath:
+			refreshes.Add(1)
+			time.Sleep(10 * time.Millisecond)
+			w.Write([]byte(`{"result":"ok","token":{"session":"fresh","refresh":"refresh"}}`))
+		case r.Header.Get("Authorization") != "Bearer fresh":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"result":"error","errors":[{"status":401,"title":"unauthorized"}]}`))
+		default:
+			w.Write([]byte(`{"result":"ok","data":[]}`))
+		}
+	}))
+	c.creds.set("expired", "refresh")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Manga.GetMangaList(nil); err != nil {
+				t.Errorf("expected request to be replayed after refresh: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := refreshes.Load(); n != 1 {
+		t.Errorf("expected exactly 1 refresh, got %d", n)
+	}
+}
+
+func TestRefreshBeforeExpiry(t *testing.T) {
+	fresh := fakeJWT(time.Now().Add(15 * time.Minute))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/"+RefreshTokenPath {
+			json.NewEncoder(w).Encode(map[string]any{
+				"result": "ok",
+				"token":  map[string]string{"session": fresh, "refresh": "refresh"},
+			})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+fresh {
+			t.Errorf("request sent with expired token")
+		}
+		w.Write([]byte(`{"result":"ok","data":[]}`))
+	}))
+	c.creds.set(fakeJWT(time.Now().Add(-time.Minute)), "refresh")
+
+	if _, err := c.Manga.GetMangaList(nil); err != nil {
+		t.Fatalf("get manga list: %s", err)
+	}
+}
+
+func TestPasswordLogin(t *testing.T) {
+	var grants []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Header.Get("Authorization") != "" {
+			t.Error("token request sent with Authorization header")
+		}
+		if r.Form.Get("client_id") != "id" || r.Form.Get("client_secret") != "secret" {
+			t.Errorf("unexpected client credentials %v", r.Form)
+		}
+		grant := r.Form.Get("grant_type")
+		grants = append(grants, grant)
+		if grant == "password" && r.Form.Get("password") != "password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"Invalid user credentials"}`))
+			return
+		}
+		fmt.Fprintf(w, `{"access_token":"access-%d","refresh_token":"refresh","token_type":"Bearer","expires_in":900}`, len(grants))
+	})
+	mux.HandleFunc("/user/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-3" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"result":"ok","data":{"id":"me"}}`))
+	})
+	c := newTestClient(t, mux, WithOAuthClient("id", "secret"))
+	c.authURL = c.apiURL("token").String()
+
+	_, err := c.Auth.PasswordLogin("user", "wrong")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Errors[0].Title != "invalid_grant" {
+		t.Fatalf("expected invalid_grant APIError, got %v", err)
+	}
+
+	tok, err := c.Auth.PasswordLogin("user", "password")
+	if err != nil {
+		t.Fatalf("password login: %s", err)
+	}
+	if tok.AccessToken != "access-2" || time.Until(tok.ExpiresAt) < 14*time.Minute {
+		t.Errorf("unexpected token %+v", tok)
+	}
+	if !c.Auth.IsLoggedIn() || c.Auth.GetRefreshToken() != "refresh" {
+		t.Error("client not logged in after password login")
+	}
+
+	// The 401 for the stale access token triggers the refresh grant.
+	if _, err = c.User.GetLoggedUser(); err != nil {
+		t.Fatalf("get logged user: %s", err)
+	}
+	if grants[len(grants)-1] != "refresh_token" {
+		t.Errorf("expected refresh grant, got %v", grants)
+	}
+}
+
+func TestCheckPermissions(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+PermissionPath {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") == "Bearer other" {
+			w.Write([]byte(`{"result":"ok","isAuthenticated":true,"roles":["ROLE_ADMIN"],"permissions":["manga.edit"]}`))
+			return
+		}
+		w.Write([]byte(`{"result":"ok","isAuthenticated":true,"roles":["ROLE_USER"],"permissions":["manga.view"]}`))
+	}))
+	c.creds.set("own", "refresh")
+
+	r, err := c.Auth.CheckPermissions("other")
+	if err != nil {
+		t.Fatalf("check permissions: %s", err)
+	}
+	if !r.IsAuth || !r.HasPermission("manga.edit") || !r.HasRole("ROLE_ADMIN") {
+		t.Errorf("unexpected response %+v", r)
+	}
+	if session, _ := c.creds.get(); session != "own" {
+		t.Errorf("client token was replaced with %q", session)
+	}
+
+	r, err = c.Auth.CheckPermissions("")
+	if err != nil {
+		t.Fatalf("check own permissions: %s", err)
+	}
+	if r.HasPermission("manga.edit") || !r.HasPermission("manga.view") {
+		t.Errorf("unexpected response %+v", r)
+	}
+}
+
+func TestTokenStore(t *testing.T) {
+	api := &fakeAuthAPI{}
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	c := newTestClient(t, api, WithTokenStore(NewFileTokenStore(path)))
+	if err := c.Auth.Login("user", "password"); err != nil {
+		t.Fatalf("login: %s", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("tokens not saved: %s", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected 0600 token file, got %o", perm)
+	}
+
+	// A new client resumes the saved session.
+	resumed := NewDexClient(WithBaseURL(c.baseURL.String()), WithTokenStore(NewFileTokenStore(path)))
+	ok, err := resumed.Auth.ResumeSession()
+	if err != nil || !ok {
+		t.Fatalf("resume session: %v, %v", ok, err)
+	}
+	if !resumed.Auth.IsLoggedIn() || resumed.Auth.GetRefreshToken() != "refresh" {
+		t.Error("resumed client is not logged in")
+	}
+
+	if err = resumed.Auth.Logout(); err != nil {
+		t.Fatalf("logout: %s", err)
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("tokens not cleared on logout: %v", err)
+	}
+}
+
+// failingTokenStore : TokenStore whose saves fail.
+type failingTokenStore struct {
+	MemoryTokenStore
+}
+
+func (*failingTokenStore) Save(*StoredToken) error {
+	return errors.New("disk full")
+}
+
+func TestTokenStoreFailure(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/"+LoginPath || r.URL.Path == "/"+RefreshTokenPath:
+			w.Write([]byte(`{"result":"ok","token":{"session":"fresh","refresh":"refresh"}}`))
+		case r.Header.Get("Authorization") != "Bearer fresh":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"result":"error","errors":[{"status":401,"title":"unauthorized"}]}`))
+		default:
+			w.Write([]byte(`{"result":"ok","data":[]}`))
+		}
+	}), WithTokenStore(&failingTokenStore{}))
+
+	// Explicit logins report that the session was not saved.
+	if err := c.Auth.Login("user", "password"); err == nil {
+		t.Error("expected login to report the token store failure")
+	}
+
+	// Refreshes while sending another request do not fail it.
+	c.creds.set("expired", "refresh")
+	if _, err := c.Manga.GetMangaList(nil); err != nil {
+		t.Errorf("expected request to succeed after refresh: %s", err)
+	}
+	if session, _ := c.creds.get(); session != "fresh" {
+		t.Errorf("refreshed token not used, got %q", session)
+	}
+}
@@ -0,0 +1,295 @@
+package mangodex
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTLs : How long responses of rarely changing endpoints are cached, keyed by path template.
+var DefaultCacheTTLs = map[string]time.Duration{
+	MangaPath:          10 * time.Minute,
+	MangaAggregatePath: 10 * time.Minute,
+	MangaTagPath:       24 * time.Hour,
+}
+
+// CachedResponse : A response stored by a ResponseCache.
+type CachedResponse struct {
+	// Key : Cache key of the response, which is the URL of the request.
+	Key    string      `json:"key"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+	// Expires : When the response has to be revalidated with the API before it is used again.
+	Expires time.Time `json:"expires"`
+}
+
+// ResponseCache : Stores responses of GET requests to the API. Caches must be safe for concurrent use.
+type ResponseCache interface {
+	// Get : Get a cached response, and whether it was found. Expired responses are also returned,
+	// so that they can be revalidated.
+	Get(key string) (*CachedResponse, bool)
+	// Set : Add or replace a cached response.
+	Set(r *CachedResponse) error
+	// DeletePrefix : Remove all responses whose key starts with prefix.
+	DeletePrefix(prefix string) error
+}
+
+// WithResponseCache : Cache responses of GET requests to the endpoints in ttls, keyed by path template,
+// for the given durations. DefaultCacheTTLs is used if ttls is nil. Expired responses are revalidated
+// with the ETag or Last-Modified header of the response, if it had one.
+// If any user endpoint, such as CheckIfMangaFollowedPath, is cached, the whole cache is cleared whenever
+// the client logs in or out, so that responses are never served to another user.
+func WithResponseCache(cache ResponseCache, ttls map[string]time.Duration) Option {
+	return func(c *DexClient) {
+		if ttls == nil {
+			ttls = DefaultCacheTTLs
+		}
+		c.responseCache = cache
+		c.cacheTTLs = ttls
+	}
+}
+
+// InvalidateCache : Remove the cached responses of the endpoints at paths relative to the API base,
+// including those with query parameters or further path segments. An empty path clears the whole cache.
+func (c *DexClient) InvalidateCache(paths ...string) error {
+	if c.responseCache == nil {
+		return nil
+	}
+	var errs []error
+	for _, path := range paths {
+		errs = append(errs, c.responseCache.DeletePrefix(strings.TrimSuffix(c.apiURL(path).String(), "/")))
+	}
+	return errors.Join(errs...)
+}
+
+// userRoutes : Endpoints whose responses depend on the logged in user.
+var userRoutes = []string{
+	CheckIfMangaFollowedPath,
+	GetUserFollowedMangaListPath,
+	GetLoggedUserPath,
+	MangaReadMarkersPath,
+}
+
+// userChanged : Remove cached responses of the previous user after the credentials of the client changed.
+// The whole cache is cleared, as not all user endpoints can be matched by prefix.
+func (c *DexClient) userChanged(ctx context.Context) {
+	for _, route := range userRoutes {
+		if c.cacheTTLs[route] > 0 {
+			c.dropCached(ctx, "")
+			return
+		}
+	}
+}
+
+// dropCached : InvalidateCache after a successful request. Failures are logged rather than returned,
+// as the request itself has already succeeded.
+func (c *DexClient) dropCached(ctx context.Context, paths ...string) {
+	if err := c.InvalidateCache(paths...); err != nil {
+		c.logCacheError(ctx, err)
+	}
+}
+
+// uncachedIDs : Endpoints matching a path template in place of an ID, such as "manga/random" for MangaPath,
+// whose responses are not those of a single resource.
+var uncachedIDs = []string{"random", "draft"}
+
+// cacheTTL : Get how long the response of a request may be cached for, if at all.
+// Requests with their own credentials are never cached.
+func (c *DexClient) cacheTTL(ar *apiRequest) (time.Duration, bool) {
+	if c.responseCache == nil || ar.method != http.MethodGet || ar.anonymous || ar.header != nil {
+		return 0, false
+	}
+	ttl, ok := c.cacheTTLs[ar.route]
+	if !ok || ttl <= 0 {
+		return 0, false
+	}
+	for _, id := range c.routeIDs(ar.url, ar.route) {
+		if slices.Contains(uncachedIDs, id) {
+			return 0, false
+		}
+	}
+	return ttl, true
+}
+
+// doCached : Send a GET request, using a cached response while it has not expired.
+func (c *DexClient) doCached(ctx context.Context, ar *apiRequest, ttl time.Duration) (*http.Response, error) {
+	cached, ok := c.responseCache.Get(ar.url)
+	if ok && time.Now().Before(cached.Expires) {
+		c.logCacheHit(ctx, ar)
+		return cached.response(), nil
+	}
+
+	// Ask the API to confirm that an expired response is still valid, rather than send it again.
+	if ok {
+		ar.header = http.Header{}
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			ar.header.Set("If-None-Match", etag)
+		}
+		if modified := cached.Header.Get("Last-Modified"); modified != "" {
+			ar.header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := c.doAttempts(ctx, ar)
+	if ok && hasStatus(err, http.StatusNotModified) {
+		cached.Expires = time.Now().Add(ttl)
+		// The cache only saves requests, so failing to update it does not fail the request.
+		_ = c.responseCache.Set(cached)
+		return cached.response(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	cached = &CachedResponse{
+		Key:     ar.url,
+		Header:  resp.Header,
+		Body:    body,
+		Expires: time.Now().Add(ttl),
+	}
+	if !strings.Contains(resp.Header.Get("Cache-Control"), "no-store") {
+		_ = c.responseCache.Set(cached)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// response : Build a response from the cached one.
+func (r *CachedResponse) response() *http.Response {
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Length", strconv.Itoa(len(r.Body)))
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+	}
+}
+
+// MemoryResponseCache : ResponseCache keeping responses in memory.
+type MemoryResponseCache struct {
+	mu        sync.Mutex
+	responses map[string]CachedResponse
+}
+
+// NewMemoryResponseCache : Create an empty MemoryResponseCache.
+func NewMemoryResponseCache() *MemoryResponseCache {
+	return &MemoryResponseCache{responses: map[string]CachedResponse{}}
+}
+
+func (m *MemoryResponseCache) Get(key string) (*CachedResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.responses[key]
+	if !ok {
+		return nil, false
+	}
+	return &r, true
+}
+
+func (m *MemoryResponseCache) Set(r *CachedResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.responses[r.Key] = *r
+	return nil
+}
+
+func (m *MemoryResponseCache) DeletePrefix(prefix string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.responses {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.responses, key)
+		}
+	}
+	return nil
+}
+
+// FileResponseCache : ResponseCache storing each response as a JSON file in a directory.
+type FileResponseCache struct {
+	dir string
+}
+
+// NewFileResponseCache : Create a FileResponseCache in dir, which is created when the first response is saved.
+func NewFileResponseCache(dir string) *FileResponseCache {
+	return &FileResponseCache{dir: dir}
+}
+
+func (f *FileResponseCache) Get(key string) (*CachedResponse, bool) {
+	data, err := os.ReadFile(f.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var r CachedResponse
+	if err = json.Unmarshal(data, &r); err != nil || r.Key != key {
+		return nil, false
+	}
+	return &r, true
+}
+
+func (f *FileResponseCache) Set(r *CachedResponse) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(f.path(r.Key), data, 0o600)
+}
+
+// DeletePrefix : Remove matching responses. Every saved response is read to find its key.
+func (f *FileResponseCache) DeletePrefix(prefix string) error {
+	entries, err := os.ReadDir(f.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(f.dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var r CachedResponse
+		if json.Unmarshal(data, &r) != nil || strings.HasPrefix(r.Key, prefix) {
+			if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// path : Get the file of a response, named after the hash of its key.
+func (f *FileResponseCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:])+".json")
+}
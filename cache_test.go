@@ -0,0 +1,181 @@
+package mangodex
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCachedAPI : Fake of the API serving a manga with an ETag, tags and the follow endpoints.
+type fakeCachedAPI struct {
+	mu       sync.Mutex
+	requests map[string]int
+	notMod   int
+	version  string
+}
+
+func (f *fakeCachedAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests[r.Method+" "+r.URL.Path]++
+
+	switch r.URL.Path {
+	case "/manga/abc":
+		etag := `"` + f.version + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			f.notMod++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(`{"result":"ok","data":{"id":"abc","attributes":{"title":{"en":"` + f.version + `"}}}}`))
+	case "/manga/tag":
+		w.Write([]byte(`{"result":"ok","response":"collection","data":[{"id":"t1","type":"tag","attributes":{"name":{"en":"Action"}}}],"total":1}`))
+	default:
+		w.Write([]byte(`{"result":"ok"}`))
+	}
+}
+
+func (f *fakeCachedAPI) count(key string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[key]
+}
+
+func TestResponseCache(t *testing.T) {
+	for name, cache := range map[string]ResponseCache{
+		"memory": NewMemoryResponseCache(),
+		"file":   NewFileResponseCache(t.TempDir()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := &fakeCachedAPI{requests: map[string]int{}, version: "v1"}
+			ttls := map[string]time.Duration{MangaPath: time.Hour, MangaTagPath: time.Hour, CheckIfMangaFollowedPath: time.Hour}
+			c := newTestClient(t, f, WithResponseCache(cache, ttls))
+
+			for i := 0; i < 3; i++ {
+				m, err := c.Manga.GetManga("abc", nil)
+				if err != nil || m.Manga.GetTitle("en") != "v1" {
+					t.Fatalf("get manga: %v, %v", m, err)
+				}
+				tags, err := c.Manga.GetMangaTags()
+				if err != nil || len(tags.Data) != 1 || tags.Data[0].GetName("en") != "Action" {
+					t.Fatalf("get tags: %v, %v", tags, err)
+				}
+				if _, err = c.Manga.CheckIfMangaFollowed("abc"); err != nil {
+					t.Fatalf("check follow: %s", err)
+				}
+			}
+			if n := f.count("GET /manga/abc"); n != 1 {
+				t.Errorf("manga fetched %d times, want 1", n)
+			}
+			if n := f.count("GET /manga/tag"); n != 1 {
+				t.Errorf("tags fetched %d times, want 1", n)
+			}
+
+			// Expired responses are revalidated with their ETag.
+			expire := func() {
+				r, _ := cache.Get(c.apiURL("manga/abc").String())
+				r.Expires = time.Now().Add(-time.Second)
+				cache.Set(r)
+			}
+			expire()
+			if m, err := c.Manga.GetManga("abc", nil); err != nil || m.Manga.GetTitle("en") != "v1" {
+				t.Fatalf("get revalidated manga: %v, %v", m, err)
+			}
+			f.mu.Lock()
+			f.version = "v2"
+			f.mu.Unlock()
+			expire()
+			if m, err := c.Manga.GetManga("abc", nil); err != nil || m.Manga.GetTitle("en") != "v2" {
+				t.Fatalf("get changed manga: %v, %v", m, err)
+			}
+			if f.notMod != 1 || f.count("GET /manga/abc") != 3 {
+				t.Errorf("expected 1 revalidation of 3 requests, got %d of %d", f.notMod, f.count("GET /manga/abc"))
+			}
+
+			// Following a manga invalidates its follow status.
+			if _, err := c.Manga.ToggleMangaFollowStatus("abc", true); err != nil {
+				t.Fatalf("follow: %s", err)
+			}
+			if _, err := c.Manga.CheckIfMangaFollowed("abc"); err != nil {
+				t.Fatalf("check follow: %s", err)
+			}
+			if n := f.count("GET /user/follows/manga/abc"); n != 2 {
+				t.Errorf("follow status fetched %d times, want 2", n)
+			}
+			if _, ok := cache.Get(c.apiURL("manga/abc").String()); !ok {
+				t.Error("unrelated response was invalidated")
+			}
+
+			// Endpoints only sharing the path template of a resource are not cached.
+			for i := 0; i < 2; i++ {
+				if err := c.RequestAndDecode(context.Background(), http.MethodGet, c.apiURL("manga/random").String(), nil, &SingleManga{}); err != nil {
+					t.Fatalf("get random manga: %s", err)
+				}
+			}
+			if n := f.count("GET /manga/random"); n != 2 {
+				t.Errorf("random manga fetched %d times, want 2", n)
+			}
+		})
+	}
+}
+
+// failingCache : ResponseCache whose invalidations fail.
+type failingCache struct {
+	ResponseCache
+}
+
+func (failingCache) DeletePrefix(prefix string) error {
+	return errors.New("disk full")
+}
+
+func TestResponseCacheInvalidationFailure(t *testing.T) {
+	f := &fakeCachedAPI{requests: map[string]int{}, version: "v1"}
+	c := newTestClient(t, f, WithResponseCache(failingCache{NewMemoryResponseCache()}, nil))
+
+	// The follow succeeded, so failing to invalidate the cache must not report it as failed.
+	if _, err := c.Manga.ToggleMangaFollowStatus("abc", true); err != nil {
+		t.Errorf("follow: %s", err)
+	}
+	if _, err := c.Chapter.SetReadUnreadMangaChapters("abc", []string{"c1"}, nil); err != nil {
+		t.Errorf("set read markers: %s", err)
+	}
+}
+
+func TestResponseCacheUserChange(t *testing.T) {
+	f := &fakeCachedAPI{requests: map[string]int{}, version: "v1"}
+	ttls := map[string]time.Duration{MangaPath: time.Hour, CheckIfMangaFollowedPath: time.Hour}
+	c := newTestClient(t, f, WithResponseCache(NewMemoryResponseCache(), ttls))
+
+	check := func() {
+		t.Helper()
+		if _, err := c.Manga.CheckIfMangaFollowed("abc"); err != nil {
+			t.Fatalf("check follow: %s", err)
+		}
+	}
+	check()
+	check()
+	if err := c.Auth.Login("other", "password"); err != nil {
+		t.Fatalf("login: %s", err)
+	}
+	check()
+	if err := c.Auth.Logout(); err != nil {
+		t.Fatalf("logout: %s", err)
+	}
+	check()
+	if n := f.count("GET /user/follows/manga/abc"); n != 3 {
+		t.Errorf("follow status fetched %d times, want 3", n)
+	}
+
+	// Without cached user endpoints, logging in keeps the cache.
+	c = newTestClient(t, f, WithResponseCache(NewMemoryResponseCache(), nil))
+	c.Manga.GetManga("abc", nil)
+	c.Auth.Login("other", "password")
+	c.Manga.GetManga("abc", nil)
+	if n := f.count("GET /manga/abc"); n != 1 {
+		t.Errorf("manga fetched %d times, want 1", n)
+	}
+}
@@ -0,0 +1,94 @@
+package mangodex
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WriteCBZ : Write a chapter as a CBZ archive, with its pages in order followed by a ComicInfo.xml.
+// The pages are fetched with client, which must be for the same chapter.
+func WriteCBZ(ctx context.Context, w io.Writer, manga *Manga, chapter *Chapter, client *MDHomeClient) error {
+	modified, err := time.Parse(time.RFC3339, chapter.Attributes.PublishAt)
+	if err != nil {
+		modified = time.Now()
+	}
+
+	zw := zip.NewWriter(w)
+	for i, filename := range client.Pages {
+		// Images are already compressed, so they are stored as is.
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     PageName(i, len(client.Pages), filename),
+			Method:   zip.Store,
+			Modified: modified,
+		})
+		if err != nil {
+			return err
+		}
+		if err = copyPage(ctx, fw, client, filename); err != nil {
+			return fmt.Errorf("page %d: %w", i+1, err)
+		}
+	}
+
+	fw, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     "ComicInfo.xml",
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = io.WriteString(fw, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(fw)
+	enc.Indent("", "  ")
+	if err = enc.Encode(NewComicInfo(manga, chapter, len(client.Pages))); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// WriteCBZFile : WriteCBZ to a file at path. The file only appears once it has been written completely.
+func WriteCBZFile(ctx context.Context, path string, manga *Manga, chapter *Chapter, client *MDHomeClient) error {
+	return writeFileFunc(path, func(w io.Writer) error {
+		return WriteCBZ(ctx, w, manga, chapter, client)
+	})
+}
+
+// copyPage : Stream a page to w.
+func copyPage(ctx context.Context, w io.Writer, client *MDHomeClient, filename string) error {
+	ps, err := client.GetChapterPageStream(ctx, filename)
+	if err != nil {
+		return err
+	}
+	defer ps.Close()
+	_, err = io.Copy(w, ps)
+	return err
+}
+
+// writeFileFunc : Create the file at path with the output of write, through a temporary file
+// so that an incomplete file is never left behind.
+func writeFileFunc(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err = tmp.Chmod(0o644); err == nil {
+		err = write(tmp)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
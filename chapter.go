@@ -2,11 +2,14 @@ package mangodex
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 const (
@@ -104,8 +107,7 @@ func (s *ChapterService) GetMangaChapters(id string, params *ListChapterParams)
 
 // GetMangaChaptersContext : GetMangaChapters with custom context.
 func (s *ChapterService) GetMangaChaptersContext(ctx context.Context, id string, params *ListChapterParams) (*ChapterList, error) {
-	u, _ := url.Parse(BaseAPI)
-	u.Path = fmt.Sprintf(MangaChaptersPath, id)
+	u := s.client.apiURL(fmt.Sprintf(MangaChaptersPath, id))
 
 	// Set request parameters
 	u.RawQuery = EncodeParams(params)
@@ -133,8 +135,7 @@ func (s *ChapterService) GetMangaChapter(id string, params *GetChapterParams) (*
 }
 
 func (s *ChapterService) GetMangaChapterWithContext(ctx context.Context, id string, params *GetChapterParams) (*SingleChapter, error) {
-	u, _ := url.Parse(BaseAPI)
-	u.Path = fmt.Sprintf(MangaChapterPath, id)
+	u := s.client.apiURL(fmt.Sprintf(MangaChapterPath, id))
 
 	u.RawQuery = EncodeParams(params)
 
@@ -161,8 +162,7 @@ func (s *ChapterService) GetReadMangaChapters(id string) (*ChapterReadMarkers, e
 
 // GetReadMangaChaptersContext : GetReadMangaChapters with custom context.
 func (s *ChapterService) GetReadMangaChaptersContext(ctx context.Context, id string) (*ChapterReadMarkers, error) {
-	u, _ := url.Parse(BaseAPI)
-	u.Path = fmt.Sprintf(MangaReadMarkersPath, id)
+	u := s.client.apiURL(fmt.Sprintf(MangaReadMarkersPath, id))
 
 	var rmr ChapterReadMarkers
 	err := s.client.RequestAndDecode(ctx, http.MethodGet, u.String(), nil, &rmr)
@@ -176,8 +176,7 @@ func (s *ChapterService) SetReadUnreadMangaChapters(id string, read, unRead []st
 
 // SetReadUnreadMangaChaptersContext : SetReadUnreadMangaChapters with custom context.
 func (s *ChapterService) SetReadUnreadMangaChaptersContext(ctx context.Context, id string, read, unRead []string) (*Response, error) {
-	u, _ := url.Parse(BaseAPI)
-	u.Path = fmt.Sprintf(MangaReadMarkersPath, id)
+	u := s.client.apiURL(fmt.Sprintf(MangaReadMarkersPath, id))
 
 	// Set request body.
 	req := map[string][]string{
@@ -190,6 +189,73 @@ func (s *ChapterService) SetReadUnreadMangaChaptersContext(ctx context.Context,
 	}
 
 	var r Response
-	err = s.client.RequestAndDecode(ctx, http.MethodPost, u.String(), bytes.NewBuffer(rBytes), &r)
-	return &r, err
+	if err = s.client.RequestAndDecode(ctx, http.MethodPost, u.String(), bytes.NewBuffer(rBytes), &r); err != nil {
+		return &r, err
+	}
+	s.client.dropCached(ctx, fmt.Sprintf(MangaReadMarkersPath, id))
+	return &r, nil
+}
+
+// GetVolumeChapters : Get the chapters of a volume of a manga, using the latest upload of each chapter in a language.
+// Chapters are sorted by chapter number. External chapters are left out, as their pages are not on MangaDex.
+func (s *ChapterService) GetVolumeChapters(mangaID, volume, lang string) ([]Chapter, error) {
+	return s.GetVolumeChaptersContext(context.Background(), mangaID, volume, lang)
+}
+
+// GetVolumeChaptersContext : GetVolumeChapters with custom context.
+func (s *ChapterService) GetVolumeChaptersContext(ctx context.Context, mangaID, volume, lang string) ([]Chapter, error) {
+	agg, err := (*MangaService)(s).GetMangaAggregateContext(ctx, mangaID, &MangaAggregateParams{
+		Language: []string{lang},
+	})
+	if err != nil {
+		return nil, err
+	}
+	vol, ok := agg.Volumes[volume]
+	if !ok {
+		return nil, fmt.Errorf("volume %s of manga %s not found", volume, mangaID)
+	}
+
+	chapters := make([]Chapter, 0, len(vol.Chapters))
+	for _, ca := range vol.Chapters {
+		sc, err := s.GetMangaChapterWithContext(ctx, ca.LatestId, &GetChapterParams{
+			Includes: []string{ScanlationGroupRel},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if sc.Chapter.Attributes.ExternalURL != nil {
+			continue
+		}
+		chapters = append(chapters, sc.Chapter)
+	}
+	SortChapters(chapters)
+	return chapters, nil
+}
+
+// SortChapters : Sort chapters by volume and chapter number, numerically where possible.
+func SortChapters(chapters []Chapter) {
+	slices.SortStableFunc(chapters, func(a, b Chapter) int {
+		if c := compareNumbers(a.Attributes.Volume, b.Attributes.Volume); c != 0 {
+			return c
+		}
+		return compareNumbers(a.Attributes.Chapter, b.Attributes.Chapter)
+	})
+}
+
+// compareNumbers : Compare optional volume or chapter numbers, with missing numbers sorted last.
+func compareNumbers(a, b *string) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	fa, errA := strconv.ParseFloat(*a, 64)
+	fb, errB := strconv.ParseFloat(*b, 64)
+	if errA != nil || errB != nil {
+		return strings.Compare(*a, *b)
+	}
+	return cmp.Compare(fa, fb)
 }
@@ -0,0 +1,271 @@
+package mangodex
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestClient : Create a client pointed at a local fake of the API.
+func newTestClient(t *testing.T, handler http.Handler, opts ...Option) *DexClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewDexClient(append([]Option{WithBaseURL(srv.URL)}, opts...)...)
+}
+
+func TestClientOptions(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/manga/abc" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if ua := r.Header.Get("User-Agent"); ua != "mangodex-test" {
+			t.Errorf("unexpected user agent %q", ua)
+		}
+		if v := r.Header.Get("X-Custom"); v != "yes" {
+			t.Errorf("unexpected custom header %q", v)
+		}
+		w.Write([]byte(`{"result":"ok","data":{"id":"abc","type":"manga"}}`))
+	}), WithUserAgent("mangodex-test"), WithHeader("X-Custom", "yes"))
+
+	m, err := c.Manga.GetManga("abc", nil)
+	if err != nil {
+		t.Fatalf("get manga: %s", err)
+	}
+	if m.Manga.ID != "abc" {
+		t.Errorf("unexpected manga id %q", m.Manga.ID)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "req-1")
+		switch r.URL.Path {
+		case "/manga/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"result":"error","errors":[{"id":"e","status":404,"title":"not_found","detail":"Manga not found"}]}`))
+		case "/user/follows/manga/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"result":"error","errors":[{"status":404,"title":"not_found"}]}`))
+		default:
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("<html>Bad Gateway</html>"))
+		}
+	}))
+
+	_, err := c.Manga.GetManga("missing", nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !IsNotFound(err) {
+		t.Fatalf("expected not found APIError, got %v", err)
+	}
+	if apiErr.RequestID != "req-1" || len(apiErr.Errors) != 1 || apiErr.Errors[0].Detail != "Manga not found" {
+		t.Errorf("unexpected APIError %+v", apiErr)
+	}
+
+	if followed, err := c.Manga.CheckIfMangaFollowed("missing"); err != nil || followed {
+		t.Errorf("expected unfollowed manga, got %v, %v", followed, err)
+	}
+
+	_, err = c.Manga.GetMangaList(nil)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected bad gateway APIError, got %v", err)
+	}
+	if apiErr.Body != "<html>Bad Gateway</html>" || apiErr.RetryAfter != 3*time.Second {
+		t.Errorf("unexpected APIError %+v", apiErr)
+	}
+}
+
+func TestMatchRoute(t *testing.T) {
+	tests := map[string]string{
+		"manga":                    MangaListPath,
+		"/manga/abc":               MangaPath,
+		"manga/tag":                MangaTagPath,
+		"manga/abc/feed":           MangaChaptersPath,
+		"manga/abc/follow":         ToggleMangaFollowPath,
+		"user/follows/manga":       GetUserFollowedMangaListPath,
+		"user/follows/manga/abc":   CheckIfMangaFollowedPath,
+		"at-home/server/abc":       GetMDHomeURLPath,
+		"auth/check":               PermissionPath,
+		"not/a/known/endpoint/abc": "",
+	}
+	for path, want := range tests {
+		if got := matchRoute(path); got != want {
+			t.Errorf("matchRoute(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestRateLimitBucket(t *testing.T) {
+	now := time.Now()
+	b := newBucket(RateLimit{Rate: 2, Burst: 2})
+	b.last = now
+	for i := 0; i < 2; i++ {
+		if d, _ := b.reserve(now); d != 0 {
+			t.Fatalf("request %d within burst waited %s", i, d)
+		}
+	}
+	if d, _ := b.reserve(now); d != 500*time.Millisecond {
+		t.Errorf("expected 500ms wait after burst, got %s", d)
+	}
+
+	b.pause(now.Add(time.Minute))
+	if d, reserved := b.reserve(now); reserved || d != time.Minute {
+		t.Errorf("expected paused bucket, got %s, %v", d, reserved)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	var attempts int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"chapterIdsRead":["a"],"chapterIdsUnread":null}` {
+			t.Errorf("unexpected body on attempt %d: %s", attempts, body)
+		}
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"result":"ok"}`))
+	}), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, RetryPOST: true}))
+
+	if _, err := c.Chapter.SetReadUnreadMangaChapters("abc", []string{"a"}, nil); err != nil {
+		t.Fatalf("expected request to succeed after retries: %s", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+
+	// Without RetryPOST, a POST is only attempted once.
+	attempts = 0
+	c.retry.RetryPOST = false
+	if _, err := c.Chapter.SetReadUnreadMangaChapters("abc", []string{"a"}, nil); err == nil {
+		t.Error("expected POST to fail without retries")
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var endpoints []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Trace") != "inner" {
+			t.Errorf("middleware header not sent on %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"result":"ok"}`))
+	}),
+		WithMiddleware(func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				req.Header.Set("X-Trace", "outer")
+				return next(req)
+			}
+		}),
+		WithMiddleware(func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				if req.Header.Get("X-Trace") != "outer" {
+					t.Error("middleware called out of order")
+				}
+				req.Header.Set("X-Trace", "inner")
+				return next(req)
+			}
+		}, func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				endpoints = append(endpoints, EndpointFromContext(req.Context()))
+				return next(req)
+			}
+		}))
+
+	c.Manga.GetMangaList(nil)
+	c.Manga.GetManga("abc", nil)
+	c.Chapter.GetMangaChapters("abc", nil)
+	c.Request(context.Background(), http.MethodGet, c.apiURL("unknown/endpoint/here").String(), nil)
+	want := []string{"manga.list", "manga.get", "chapter.feed", ""}
+	if !slices.Equal(endpoints, want) {
+		t.Errorf("got endpoints %q, want %q", endpoints, want)
+	}
+}
+
+func TestInvalidBaseURL(t *testing.T) {
+	for _, base := range []string{"http://[::1", "api.mangadex.org", "ftp://example.com", ""} {
+		c := NewDexClient(WithBaseURL(base))
+		if _, err := c.Manga.GetManga("abc", nil); !errors.Is(err, ErrInvalidBaseURL) {
+			t.Errorf("base URL %q: expected ErrInvalidBaseURL, got %v", base, err)
+		}
+	}
+
+	c := NewDexClient(WithBaseURL("http://localhost:8080/api/"))
+	if u := c.apiURL(MangaListPath).String(); u != "http://localhost:8080/api/manga" {
+		t.Errorf("unexpected API URL %s", u)
+	}
+	if r := c.route("http://localhost:8080/api/manga/abc"); r != MangaPath {
+		t.Errorf("unexpected route %q", r)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		arrived = map[string][]time.Time{}
+		resume  = map[string]int64{}
+	)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		arrived[r.URL.Path] = append(arrived[r.URL.Path], time.Now())
+
+		// The first request to an endpoint uses up its limit until the next second.
+		if len(arrived[r.URL.Path]) == 1 && !strings.HasSuffix(r.URL.Path, "/read") {
+			resume[r.URL.Path] = time.Now().Unix() + 1
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Retry-After", strconv.FormatInt(resume[r.URL.Path], 10))
+			if r.URL.Path == "/manga" {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+		}
+		w.Write([]byte(`{"result":"ok"}`))
+	}), WithRouteRateLimit(MangaReadMarkersPath, 20, 1))
+
+	// Requests to a route with its own limit are spaced out, without slowing down other routes.
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := c.Chapter.GetReadMangaChapters("limited"); err != nil {
+			t.Fatalf("get read markers: %s", err)
+		}
+	}
+	if d := time.Since(start); d < 90*time.Millisecond {
+		t.Errorf("3 requests at 20/s with a burst of 1 took %s", d)
+	}
+
+	// A route without remaining requests is paused until the time given by the API. So is one that was
+	// rate limited, while other routes are not affected.
+	c.Manga.GetManga("abc", nil)
+	if _, err := c.Manga.GetMangaList(nil); !IsRateLimited(err) {
+		t.Fatalf("expected rate limited error, got %v", err)
+	}
+	unpaused := time.Now()
+	c.Chapter.GetMangaChapter("abc", nil)
+	c.Manga.GetManga("abc", nil)
+	c.Manga.GetMangaList(nil)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if d := arrived["/chapter/abc"][0].Sub(unpaused); d > 200*time.Millisecond {
+		t.Errorf("unrelated route waited %s", d)
+	}
+	for _, path := range []string{"/manga/abc", "/manga"} {
+		if got, want := arrived[path][1], time.Unix(resume[path], 0); got.Before(want) {
+			t.Errorf("request to %s sent at %s, before the limit reset at %s", path, got, want)
+		}
+	}
+}
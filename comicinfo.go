@@ -0,0 +1,124 @@
+package mangodex
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ComicInfo : Metadata written to ComicInfo.xml in comic archives, as read by Komga, Kavita and most comic readers.
+// https://anansi-project.github.io/docs/comicinfo/schemas/v2.0
+type ComicInfo struct {
+	XMLName     xml.Name        `xml:"ComicInfo"`
+	Title       string          `xml:"Title,omitempty"`
+	Series      string          `xml:"Series,omitempty"`
+	Number      string          `xml:"Number,omitempty"`
+	Volume      string          `xml:"Volume,omitempty"`
+	Summary     string          `xml:"Summary,omitempty"`
+	Year        int             `xml:"Year,omitempty"`
+	Month       int             `xml:"Month,omitempty"`
+	Day         int             `xml:"Day,omitempty"`
+	Writer      string          `xml:"Writer,omitempty"`
+	Penciller   string          `xml:"Penciller,omitempty"`
+	Translator  string          `xml:"Translator,omitempty"`
+	Genre       string          `xml:"Genre,omitempty"`
+	Tags        string          `xml:"Tags,omitempty"`
+	Web         string          `xml:"Web,omitempty"`
+	PageCount   int             `xml:"PageCount,omitempty"`
+	LanguageISO string          `xml:"LanguageISO,omitempty"`
+	Manga       string          `xml:"Manga,omitempty"`
+	AgeRating   string          `xml:"AgeRating,omitempty"`
+	Pages       []ComicInfoPage `xml:"Pages>Page,omitempty"`
+}
+
+// ComicInfoPage : Page entry of a ComicInfo.
+type ComicInfoPage struct {
+	Image int    `xml:"Image,attr"`
+	Type  string `xml:"Type,attr,omitempty"`
+}
+
+// ageRatings : ComicInfo age ratings for MangaDex content ratings.
+var ageRatings = map[string]string{
+	Safe:       "Everyone",
+	Suggestive: "Teen",
+	Erotica:    "Mature 17+",
+	Porn:       "Adults Only 18+",
+}
+
+// NewComicInfo : Build the ComicInfo for a chapter with the given number of pages.
+// Author, artist and scanlation group names are only filled in if the relationships include their attributes.
+func NewComicInfo(manga *Manga, chapter *Chapter, pages int) *ComicInfo {
+	lang := chapter.Attributes.TranslatedLanguage
+	attrs := &manga.Attributes
+
+	ci := &ComicInfo{
+		Title:       chapter.GetTitle(),
+		Series:      manga.GetTitle(lang),
+		Number:      chapter.GetChapterNum(),
+		Summary:     manga.GetDescription(lang),
+		Writer:      strings.Join(relationshipNames(manga.Relationships, AuthorRel), ", "),
+		Penciller:   strings.Join(relationshipNames(manga.Relationships, ArtistRel), ", "),
+		Translator:  strings.Join(relationshipNames(chapter.Relationships, ScanlationGroupRel), ", "),
+		Web:         fmt.Sprintf("https://mangadex.org/chapter/%s", chapter.ID),
+		PageCount:   pages,
+		LanguageISO: lang,
+		Manga:       "Yes",
+	}
+	if v := chapter.Attributes.Volume; v != nil {
+		ci.Volume = *v
+	}
+	if attrs.OriginalLanguage == "ja" {
+		ci.Manga = "YesAndRightToLeft"
+	}
+	if attrs.ContentRating != nil {
+		ci.AgeRating = ageRatings[*attrs.ContentRating]
+	}
+
+	// Prefer the publish date of the chapter, falling back to the year of the manga.
+	if t, err := time.Parse(time.RFC3339, chapter.Attributes.PublishAt); err == nil {
+		ci.Year, ci.Month, ci.Day = t.Year(), int(t.Month()), t.Day()
+	} else if attrs.Year != nil {
+		ci.Year = *attrs.Year
+	}
+
+	// Genre tags and the demographic are genres, all other tags are tags.
+	var genres, tags []string
+	if d := attrs.PublicationDemographic; d != nil && *d != "" {
+		genres = append(genres, *d)
+	}
+	for _, tag := range attrs.Tags {
+		if tag.Attributes.Group == "genre" {
+			genres = append(genres, tag.GetName("en"))
+		} else {
+			tags = append(tags, tag.GetName("en"))
+		}
+	}
+	ci.Genre, ci.Tags = strings.Join(genres, ", "), strings.Join(tags, ", ")
+
+	for i := 0; i < pages; i++ {
+		p := ComicInfoPage{Image: i}
+		if i == 0 {
+			p.Type = "FrontCover"
+		}
+		ci.Pages = append(ci.Pages, p)
+	}
+	return ci
+}
+
+// relationshipNames : Get the names of all related authors, artists or scanlation groups of a type.
+func relationshipNames(rels []Relationship, typ string) []string {
+	var names []string
+	for _, rel := range rels {
+		if rel.Type != typ {
+			continue
+		}
+		switch a := rel.Attributes.(type) {
+		case *AuthorAttributes:
+			names = append(names, a.Name)
+		case *ScanlationGroupAttributes:
+			names = append(names, a.Name)
+		}
+	}
+	return names
+}
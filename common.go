@@ -28,11 +28,11 @@ func EncodeParams(params any) string {
 }
 
 type CommonResponse struct {
-	Result   string    `json:"result"`
-	Response string    `json:"response"`
-	Limit    int       `json:"limit"`
-	Offset   int       `json:"offset"`
-	Total    int       `json:"total"`
+	Result   string `json:"result"`
+	Response string `json:"response"`
+	Limit    int    `json:"limit"`
+	Offset   int    `json:"offset"`
+	Total    int    `json:"total"`
 }
 
 // Relationship : Struct containing relationships, with optional attributes for the relation.
@@ -57,7 +57,7 @@ func (a *Relationship) UnmarshalJSON(data []byte) error {
 	switch typ.Type {
 	case MangaRel:
 		a.Attributes = &MangaAttributes{}
-	case AuthorRel:
+	case AuthorRel, ArtistRel:
 		a.Attributes = &AuthorAttributes{}
 	case ScanlationGroupRel:
 		a.Attributes = &ScanlationGroupAttributes{}
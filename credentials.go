@@ -0,0 +1,82 @@
+package mangodex
+
+import (
+	"sync"
+	"time"
+)
+
+// credentials : Authentication state of a DexClient, safe for concurrent use.
+type credentials struct {
+	mu      sync.RWMutex
+	session string
+	refresh string
+	expiry  time.Time
+	oauth   bool
+}
+
+// get : Get the current session and refresh token.
+func (c *credentials) get() (session, refresh string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.session, c.refresh
+}
+
+// set : Replace both the session and refresh token issued by the legacy auth endpoints.
+func (c *credentials) set(session, refresh string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.session, c.refresh = session, refresh
+	c.expiry, c.oauth = time.Time{}, false
+}
+
+// setOAuth : Replace the tokens with ones issued by the OAuth2 token endpoint.
+func (c *credentials) setOAuth(session, refresh string, expiry time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.session, c.refresh = session, refresh
+	c.expiry, c.oauth = expiry, true
+}
+
+// expiresAt : Get the expiry of the session token, if known.
+func (c *credentials) expiresAt() (time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.expiry.IsZero() {
+		return c.expiry, true
+	}
+	return tokenExpiry(c.session)
+}
+
+// isOAuth : Check whether the tokens were issued by the OAuth2 token endpoint.
+func (c *credentials) isOAuth() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.oauth
+}
+
+// setRefresh : Replace only the refresh token.
+func (c *credentials) setRefresh(refresh string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refresh = refresh
+}
+
+// snapshot : Copy the tokens for saving to a TokenStore.
+func (c *credentials) snapshot() *StoredToken {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return &StoredToken{
+		Session:   c.session,
+		Refresh:   c.refresh,
+		ExpiresAt: c.expiry,
+		OAuth:     c.oauth,
+	}
+}
+
+// restore : Replace the tokens with ones loaded from a TokenStore.
+func (c *credentials) restore(t *StoredToken) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.session, c.refresh = t.Session, t.Refresh
+	c.expiry, c.oauth = t.ExpiresAt, t.OAuth
+}
@@ -0,0 +1,199 @@
+package mangodex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// DefaultDownloadConcurrency : Number of pages a Downloader fetches at once by default.
+const DefaultDownloadConcurrency = 4
+
+// Downloader : Downloads all pages of a chapter from MangaDex@Home with bounded concurrency.
+type Downloader struct {
+	client *MDHomeClient
+
+	// Concurrency : Maximum number of pages fetched at once. Defaults to DefaultDownloadConcurrency.
+	Concurrency int
+	// OnProgress : Called after each page finishes, successfully or not. Calls are never concurrent.
+	OnProgress func(DownloadProgress)
+
+	// sem : Limits page fetches across multiple downloaders, instead of Concurrency.
+	sem chan struct{}
+}
+
+// PageResult : Outcome of downloading a single page.
+type PageResult struct {
+	// Index : Position of the page in the chapter, starting from 0.
+	Index int
+	// Filename : Filename of the page on MangaDex@Home.
+	Filename string
+	// Name : Ordered, zero-padded name the page was written as, such as "007.png".
+	Name  string
+	Bytes int64
+	// Skipped : Whether the page was already downloaded by a previous run, and was not fetched again.
+	Skipped bool
+	Err     error
+}
+
+// DownloadProgress : Progress of a download, reported after every page.
+type DownloadProgress struct {
+	Page  PageResult
+	Done  int
+	Total int
+}
+
+// NewDownloader : Create a Downloader for the chapter of a MDHomeClient.
+func NewDownloader(client *MDHomeClient) *Downloader {
+	return &Downloader{
+		client:      client,
+		Concurrency: DefaultDownloadConcurrency,
+	}
+}
+
+// PageName : Get the ordered, zero-padded output name of the page at index, keeping the extension of filename.
+func PageName(index, total int, filename string) string {
+	width := len(strconv.Itoa(max(total, 1)))
+	return fmt.Sprintf("%0*d%s", width, index+1, path.Ext(filename))
+}
+
+// Download : Download all pages, streaming each to the writer returned by create.
+// Writers are closed after the page has been written. Failover only applies to opening a page,
+// as a page that failed while being written cannot be taken back from its writer.
+func (d *Downloader) Download(ctx context.Context, create func(index int, name string) (io.WriteCloser, error)) ([]PageResult, error) {
+	return d.run(ctx, nil, func(r *PageResult, page io.Reader) (int64, error) {
+		w, err := create(r.Index, r.Name)
+		if err != nil {
+			return 0, err
+		}
+		n, err := io.Copy(w, page)
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+		return n, err
+	})
+}
+
+// DownloadToDir : Download all pages as files into dir, which is created if required.
+// Finished pages are recorded in a Manifest in dir, so that running the download again only fetches
+// missing or damaged pages. All pages are fetched again if the chapter has been re-uploaded since.
+func (d *Downloader) DownloadToDir(ctx context.Context, dir string) ([]PageResult, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	mw, err := openManifest(dir, d.client)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.run(ctx, mw.finished, func(r *PageResult, page io.Reader) (int64, error) {
+		n, sum, err := writePageFile(filepath.Join(dir, r.Name), page)
+		if err != nil {
+			return n, err
+		}
+		return n, mw.add(r, n, sum)
+	})
+}
+
+// writePageFile : Stream a page to a temporary file next to path, and move it into place once it was read
+// completely. Returns the size and hex SHA-256 of the page, computed while it is written.
+func writePageFile(path string, page io.Reader) (int64, string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), page)
+	if err == nil {
+		err = tmp.Chmod(0o644)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return n, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), os.Rename(tmp.Name(), path)
+}
+
+// run : Fetch all pages concurrently, streaming each to write, which returns the number of bytes written.
+// Pages for which skip returns true are not fetched.
+func (d *Downloader) run(ctx context.Context, skip func(r *PageResult) bool, write func(r *PageResult, page io.Reader) (int64, error)) ([]PageResult, error) {
+	pages := d.client.Pages
+	results := make([]PageResult, len(pages))
+
+	sem := d.sem
+	if sem == nil {
+		concurrency := d.Concurrency
+		if concurrency <= 0 {
+			concurrency = DefaultDownloadConcurrency
+		}
+		sem = make(chan struct{}, concurrency)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+	for i, filename := range pages {
+		results[i] = PageResult{
+			Index:    i,
+			Filename: filename,
+			Name:     PageName(i, len(pages), filename),
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(r *PageResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if skip != nil && skip(r) {
+				r.Skipped = true
+			} else {
+				ps, err := d.client.GetChapterPageStream(ctx, r.Filename)
+				if err == nil {
+					r.Bytes, err = write(r, ps)
+					ps.Close()
+				}
+				r.Err = err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			if d.OnProgress != nil {
+				d.OnProgress(DownloadProgress{Page: *r, Done: done, Total: len(pages)})
+			}
+		}(&results[i])
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("page %s: %w", r.Name, r.Err))
+		}
+	}
+	if len(errs) != 0 {
+		return results, fmt.Errorf("%d of %d pages failed: %w", len(errs), len(pages), errors.Join(errs...))
+	}
+	return results, nil
+}
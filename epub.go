@@ -0,0 +1,200 @@
+package mangodex
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+)
+
+// EPUBExporter : Writes volumes of a manga as fixed-layout EPUB 3 books.
+type EPUBExporter struct {
+	client *DexClient
+
+	// Language : Language of the chapters included, also used for the metadata of the book.
+	Language string
+	// Quality : Quality of the pages, either "data" or "data-saver".
+	Quality string
+}
+
+// NewEPUBExporter : Create an EPUBExporter for chapters translated to lang.
+func NewEPUBExporter(client *DexClient, lang string) *EPUBExporter {
+	return &EPUBExporter{
+		client:   client,
+		Language: lang,
+		Quality:  "data",
+	}
+}
+
+// WriteVolume : Write a volume of a manga as an EPUB. The first page of the volume is used as the cover.
+func (e *EPUBExporter) WriteVolume(ctx context.Context, w io.Writer, manga *Manga, volume string) error {
+	chapters, err := e.client.Chapter.GetVolumeChaptersContext(ctx, manga.ID, volume, e.Language)
+	if err != nil {
+		return err
+	}
+
+	book := &epubBook{
+		ID:          fmt.Sprintf("urn:mangadex:manga:%s:volume:%s", manga.ID, volume),
+		Title:       fmt.Sprintf("%s Vol. %s", manga.GetTitle(e.Language), volume),
+		Language:    e.Language,
+		Description: manga.GetDescription(e.Language),
+		Creators:    append(relationshipNames(manga.Relationships, AuthorRel), relationshipNames(manga.Relationships, ArtistRel)...),
+		Modified:    time.Now().UTC().Format(time.RFC3339),
+		RTL:         manga.Attributes.OriginalLanguage == "ja",
+	}
+	for i := range chapters {
+		ec, err := fetchChapterPages(ctx, e.client, i, &chapters[i], e.Quality)
+		if err != nil {
+			return err
+		}
+		book.Chapters = append(book.Chapters, *ec)
+	}
+	return book.write(w)
+}
+
+// WriteVolumeFile : WriteVolume to a file at path. The file only appears once it has been written completely.
+func (e *EPUBExporter) WriteVolumeFile(ctx context.Context, path string, manga *Manga, volume string) error {
+	return writeFileFunc(path, func(w io.Writer) error {
+		return e.WriteVolume(ctx, w, manga, volume)
+	})
+}
+
+// epubBook : Contents and metadata of an EPUB.
+type epubBook struct {
+	ID          string
+	Title       string
+	Language    string
+	Description string
+	Creators    []string
+	Modified    string
+	RTL         bool
+	Chapters    []exportChapter
+}
+
+// write : Write the book as an EPUB container.
+func (b *epubBook) write(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	// The mimetype must be the first entry, and stored uncompressed.
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err = io.WriteString(fw, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		tmpl *template.Template
+		data any
+	}{
+		{"META-INF/container.xml", epubContainer, nil},
+		{"OEBPS/content.opf", epubPackage, b},
+		{"OEBPS/nav.xhtml", epubNav, b},
+	}
+	for _, f := range files {
+		if fw, err = zw.Create(f.name); err != nil {
+			return err
+		}
+		if err = f.tmpl.Execute(fw, f.data); err != nil {
+			return err
+		}
+	}
+
+	for _, ch := range b.Chapters {
+		for _, p := range ch.Pages {
+			if fw, err = zw.Create("OEBPS/pages/" + p.pageName() + ".xhtml"); err != nil {
+				return err
+			}
+			if err = epubPage.Execute(fw, p); err != nil {
+				return err
+			}
+
+			if fw, err = zw.CreateHeader(&zip.FileHeader{Name: "OEBPS/images/" + p.Name, Method: zip.Store}); err != nil {
+				return err
+			}
+			if _, err = fw.Write(p.Data); err != nil {
+				return err
+			}
+		}
+	}
+	return zw.Close()
+}
+
+var epubFuncs = template.FuncMap{
+	"pageName": exportPage.pageName,
+}
+
+var epubContainer = template.Must(template.New("container").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`))
+
+var epubPackage = template.Must(template.New("package").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="{{.Language | html}}">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">{{.ID | html}}</dc:identifier>
+    <dc:title>{{.Title | html}}</dc:title>
+    <dc:language>{{.Language | html}}</dc:language>
+    {{- range .Creators}}
+    <dc:creator>{{. | html}}</dc:creator>
+    {{- end}}
+    {{- if .Description}}
+    <dc:description>{{.Description | html}}</dc:description>
+    {{- end}}
+    <meta property="dcterms:modified">{{.Modified}}</meta>
+    <meta property="rendition:layout">pre-paginated</meta>
+    <meta property="rendition:orientation">portrait</meta>
+    <meta property="rendition:spread">none</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    {{- range $c, $ch := .Chapters}}{{range $i, $p := $ch.Pages}}
+    <item id="img-{{pageName $p}}" href="images/{{$p.Name}}" media-type="{{$p.MediaType}}"{{if and (eq $c 0) (eq $i 0)}} properties="cover-image"{{end}}/>
+    <item id="page-{{pageName $p}}" href="pages/{{pageName $p}}.xhtml" media-type="application/xhtml+xml"/>
+    {{- end}}{{end}}
+  </manifest>
+  <spine{{if .RTL}} page-progression-direction="rtl"{{end}}>
+    {{- range .Chapters}}{{range .Pages}}
+    <itemref idref="page-{{pageName .}}"/>
+    {{- end}}{{end}}
+  </spine>
+</package>
+`))
+
+var epubNav = template.Must(template.New("nav").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="{{.Language | html}}">
+<head><title>{{.Title | html}}</title></head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <h1>{{.Title | html}}</h1>
+    <ol>
+      {{- range .Chapters}}{{if .Pages}}
+      <li><a href="pages/{{pageName (index .Pages 0)}}.xhtml">{{.Title | html}}</a></li>
+      {{- end}}{{end}}
+    </ol>
+  </nav>
+</body>
+</html>
+`))
+
+var epubPage = template.Must(template.New("page").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head>
+  <title>{{pageName .}}</title>
+  <meta name="viewport" content="width={{.Width}}, height={{.Height}}"/>
+  <style>html, body { margin: 0; padding: 0; } img { display: block; width: {{.Width}}px; height: {{.Height}}px; }</style>
+</head>
+<body>
+  <img src="../images/{{.Name}}" alt=""/>
+</body>
+</html>
+`))
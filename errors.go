@@ -0,0 +1,103 @@
+package mangodex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxErrorBody : Upper bound on how much of an error response body is read.
+const maxErrorBody = 64 << 10
+
+// APIError : Error returned by DexClient.Request for non-200 responses.
+type APIError struct {
+	StatusCode int
+	Errors     []Error
+	RequestID  string
+	RetryAfter time.Duration
+	// Body : Raw response body, kept when it could not be decoded as an ErrorResponse.
+	Body string
+}
+
+func (e *APIError) Error() string {
+	var msg string
+	if len(e.Errors) != 0 {
+		er := ErrorResponse{Errors: e.Errors}
+		msg = strings.TrimSpace(er.GetErrors())
+	} else if e.Body != "" {
+		msg = e.Body
+	} else {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.RequestID != "" {
+		return fmt.Sprintf("non-200 status code -> (%d) %s [request %s]", e.StatusCode, msg, e.RequestID)
+	}
+	return fmt.Sprintf("non-200 status code -> (%d) %s", e.StatusCode, msg)
+}
+
+// newAPIError : Build an APIError from a non-200 response. The body is consumed but not closed.
+func newAPIError(resp *http.Response) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-ID"),
+		RetryAfter: retryAfter(resp.Header, time.Now()),
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	var er ErrorResponse
+	if err := json.Unmarshal(data, &er); err == nil && len(er.Errors) != 0 {
+		e.Errors = er.Errors
+	} else if oe, ok := decodeOAuthError(data, resp.StatusCode); ok {
+		e.Errors = []Error{oe}
+	} else {
+		e.Body = strings.TrimSpace(string(data))
+	}
+	return e
+}
+
+// retryAfter : Get how long to wait before retrying, from either the standard Retry-After
+// header or the MangaDex X-RateLimit-Retry-After header, which is a unix timestamp.
+func retryAfter(header http.Header, now time.Time) time.Duration {
+	if v := header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil && t.After(now) {
+			return t.Sub(now)
+		}
+	}
+	if v := header.Get("X-RateLimit-Retry-After"); v != "" {
+		if ts, err := strconv.ParseInt(v, 10, 64); err == nil {
+			if t := time.Unix(ts, 0); t.After(now) {
+				return t.Sub(now)
+			}
+		}
+	}
+	return 0
+}
+
+// hasStatus : Check whether err is an APIError with the given status code.
+func hasStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// IsNotFound : Check whether err is an APIError for a 404 response.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsRateLimited : Check whether err is an APIError for a 429 response.
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+// IsUnauthorized : Check whether err is an APIError for a 401 response.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
@@ -0,0 +1,89 @@
+package mangodex
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"path"
+	"strings"
+)
+
+// Fallback page size, used when the dimensions of an image cannot be decoded.
+const (
+	defaultPageWidth  = 800
+	defaultPageHeight = 1200
+)
+
+// exportPage : A page of an exported book.
+type exportPage struct {
+	Name      string
+	MediaType string
+	Width     int
+	Height    int
+	Data      []byte
+}
+
+// exportChapter : A chapter of an exported book with its pages.
+type exportChapter struct {
+	Title string
+	Pages []exportPage
+}
+
+// chapterTitle : Get the title of a chapter as shown in tables of contents.
+func chapterTitle(chapter *Chapter) string {
+	if title := chapter.GetTitle(); title != "" {
+		return fmt.Sprintf("Chapter %s: %s", chapter.GetChapterNum(), title)
+	}
+	return fmt.Sprintf("Chapter %s", chapter.GetChapterNum())
+}
+
+// fetchChapterPages : Download all pages of a chapter, along with their dimensions.
+func fetchChapterPages(ctx context.Context, client *DexClient, index int, chapter *Chapter, quality string) (*exportChapter, error) {
+	mc, err := client.AtHome.NewMDHomeClientContext(ctx, chapter.ID, quality, false)
+	if err != nil {
+		return nil, err
+	}
+
+	ec := &exportChapter{Title: chapterTitle(chapter)}
+	for i, filename := range mc.Pages {
+		data, err := mc.GetChapterPageWithContext(ctx, filename)
+		if err != nil {
+			return nil, fmt.Errorf("chapter %s page %d: %w", chapter.GetChapterNum(), i+1, err)
+		}
+
+		p := exportPage{
+			Name:      fmt.Sprintf("c%03d-%s", index+1, PageName(i, len(mc.Pages), filename)),
+			MediaType: imageMediaType(filename),
+			Width:     defaultPageWidth,
+			Height:    defaultPageHeight,
+			Data:      data,
+		}
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+			p.Width, p.Height = cfg.Width, cfg.Height
+		}
+		ec.Pages = append(ec.Pages, p)
+	}
+	return ec, nil
+}
+
+// imageMediaType : Get the media type of an image from its filename.
+func imageMediaType(filename string) string {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	}
+	return "image/jpeg"
+}
+
+// pageName : Get the name of the page without its extension.
+func (p exportPage) pageName() string {
+	return strings.TrimSuffix(p.Name, path.Ext(p.Name))
+}
@@ -0,0 +1,255 @@
+package mangodex
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testManga : Manga with the relationships and attributes used by the exporters.
+func testManga(t *testing.T) *Manga {
+	t.Helper()
+	var m Manga
+	err := json.Unmarshal([]byte(`{
+		"id": "manga",
+		"type": "manga",
+		"attributes": {
+			"title": {"en": "Test Manga"},
+			"description": {"en": "A test."},
+			"originalLanguage": "ja",
+			"publicationDemographic": "shounen",
+			"contentRating": "safe",
+			"year": 2020,
+			"tags": [
+				{"attributes": {"name": {"en": "Action"}, "group": "genre"}},
+				{"attributes": {"name": {"en": "Ninja"}, "group": "theme"}}
+			]
+		},
+		"relationships": [
+			{"id": "a", "type": "author", "attributes": {"name": "Writer Person"}},
+			{"id": "b", "type": "artist", "attributes": {"name": "Artist Person"}}
+		]
+	}`), &m)
+	if err != nil {
+		t.Fatalf("unmarshal manga: %s", err)
+	}
+	return &m
+}
+
+// testChapter : Chapter with a scanlation group relationship.
+func testChapter(t *testing.T, id, volume, chapter string) *Chapter {
+	t.Helper()
+	var c Chapter
+	err := json.Unmarshal([]byte(`{
+		"id": "`+id+`",
+		"type": "chapter",
+		"attributes": {
+			"title": "Chapter `+chapter+`",
+			"volume": "`+volume+`",
+			"chapter": "`+chapter+`",
+			"translatedLanguage": "en",
+			"publishAt": "2021-03-04T05:06:07+00:00"
+		},
+		"relationships": [
+			{"id": "g", "type": "scanlation_group", "attributes": {"name": "Group"}}
+		]
+	}`), &c)
+	if err != nil {
+		t.Fatalf("unmarshal chapter: %s", err)
+	}
+	return &c
+}
+
+func TestWriteCBZ(t *testing.T) {
+	_, c := newFakeAtHome(t, 3)
+	mc, err := c.AtHome.NewMDHomeClient("chapter", "data", false)
+	if err != nil {
+		t.Fatalf("new client: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err = WriteCBZ(context.Background(), &buf, testManga(t), testChapter(t, "chapter", "1", "2"), mc); err != nil {
+		t.Fatalf("write cbz: %s", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("read cbz: %s", err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if len(names) != 4 || names[0] != "1.png" || names[2] != "3.png" || names[3] != "ComicInfo.xml" {
+		t.Fatalf("unexpected entries %v", names)
+	}
+
+	r, _ := zr.File[3].Open()
+	data, _ := io.ReadAll(r)
+	var ci ComicInfo
+	if err = xml.Unmarshal(data, &ci); err != nil {
+		t.Fatalf("unmarshal ComicInfo: %s", err)
+	}
+	want := ComicInfo{
+		Title: "Chapter 2", Series: "Test Manga", Number: "2", Volume: "1", Summary: "A test.",
+		Year: 2021, Month: 3, Day: 4, Writer: "Writer Person", Penciller: "Artist Person", Translator: "Group",
+		Genre: "shounen, Action", Tags: "Ninja", Web: "https://mangadex.org/chapter/chapter", PageCount: 3,
+		LanguageISO: "en", Manga: "YesAndRightToLeft", AgeRating: "Everyone",
+	}
+	if len(ci.Pages) != 3 || ci.Pages[0].Type != "FrontCover" {
+		t.Errorf("unexpected pages %+v", ci.Pages)
+	}
+	ci.XMLName, ci.Pages = xml.Name{}, nil
+	if !reflect.DeepEqual(ci, want) {
+		t.Errorf("unexpected ComicInfo\n got %+v\nwant %+v", ci, want)
+	}
+}
+
+func TestWriteVolumeEPUB(t *testing.T) {
+	_, c := newFakeAtHome(t, 2)
+
+	var buf bytes.Buffer
+	if err := NewEPUBExporter(c, "en").WriteVolume(context.Background(), &buf, testManga(t), "1"); err != nil {
+		t.Fatalf("write epub: %s", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("read epub: %s", err)
+	}
+
+	files := map[string]string{}
+	for _, f := range zr.File {
+		r, _ := f.Open()
+		data, _ := io.ReadAll(r)
+		files[f.Name] = string(data)
+	}
+	if zr.File[0].Name != "mimetype" || zr.File[0].Method != zip.Store || files["mimetype"] != "application/epub+zip" {
+		t.Error("mimetype is not the first, uncompressed entry")
+	}
+	for _, name := range []string{"META-INF/container.xml", "OEBPS/images/c001-1.png", "OEBPS/pages/c002-2.xhtml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("missing %s", name)
+		}
+	}
+
+	opf := files["OEBPS/content.opf"]
+	for _, want := range []string{
+		"<dc:title>Test Manga Vol. 1</dc:title>",
+		"<dc:creator>Writer Person</dc:creator>",
+		`<meta property="rendition:layout">pre-paginated</meta>`,
+		`href="images/c001-1.png" media-type="image/png" properties="cover-image"`,
+		`page-progression-direction="rtl"`,
+	} {
+		if !strings.Contains(opf, want) {
+			t.Errorf("content.opf missing %s", want)
+		}
+	}
+	nav := files["OEBPS/nav.xhtml"]
+	if !strings.Contains(nav, `<a href="pages/c001-1.xhtml">Chapter 1: Title c1</a>`) ||
+		!strings.Contains(nav, `<a href="pages/c002-1.xhtml">Chapter 2: Title c2</a>`) || strings.Contains(nav, "Chapter 3") {
+		t.Errorf("unexpected nav document %s", nav)
+	}
+	for name, content := range files {
+		if strings.HasSuffix(name, ".xml") || strings.HasSuffix(name, ".opf") || strings.HasSuffix(name, ".xhtml") {
+			if err := xml.Unmarshal([]byte(content), new(struct{})); err != nil {
+				t.Errorf("%s is not well-formed: %s", name, err)
+			}
+		}
+	}
+}
+
+func TestWriteVolumePDF(t *testing.T) {
+	f, c := newFakeAtHome(t, 2)
+
+	// A transparent PNG and a JPEG, which is embedded as it is.
+	var pngData, jpegData bytes.Buffer
+	m := image.NewNRGBA(image.Rect(0, 0, 30, 40))
+	m.Set(1, 1, color.NRGBA{R: 255, A: 128})
+	if err := png.Encode(&pngData, m); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&jpegData, image.NewGray(image.Rect(0, 0, 50, 60)), nil); err != nil {
+		t.Fatal(err)
+	}
+	f.pages["p0-abc.png"], f.pages["p1-abc.png"] = pngData.Bytes(), jpegData.Bytes()
+
+	var buf bytes.Buffer
+	if err := NewPDFExporter(c, "en").WriteVolume(context.Background(), &buf, testManga(t), "1"); err != nil {
+		t.Fatalf("write pdf: %s", err)
+	}
+	doc := buf.String()
+
+	for _, want := range []string{
+		"/Type /Pages /Kids [5 0 R 9 0 R 13 0 R 17 0 R] /Count 4",
+		"/MediaBox [0 0 30 40]",
+		"/MediaBox [0 0 50 60]",
+		"/ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /DCTDecode",
+		"/Filter /FlateDecode /SMask 8 0 R",
+		"/Title " + pdfString("Test Manga Vol. 1"),
+		"/Author " + pdfString("Writer Person, Artist Person"),
+		"/Title " + pdfString("Chapter 1: Title c1") + " /Parent 3 0 R /Dest [5 0 R /Fit] /Next 22 0 R",
+		"/Title " + pdfString("Chapter 2: Title c2") + " /Parent 3 0 R /Dest [13 0 R /Fit] /Prev 21 0 R",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document missing %s", want)
+		}
+	}
+	if !bytes.Contains(buf.Bytes(), jpegData.Bytes()) {
+		t.Error("JPEG page was not embedded as it is")
+	}
+
+	// Every entry of the cross-reference table must point at its object.
+	start := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindStringSubmatch(doc)
+	if start == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(start[1])
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(doc[xref:], -1)
+	if len(entries) != 22 {
+		t.Fatalf("got %d objects, want 22", len(entries))
+	}
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(e[1])
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !strings.HasPrefix(doc[offset:], want) {
+			t.Errorf("xref entry %d does not point at its object", i+1)
+		}
+	}
+
+	// Chapters without pages are left out of the outline.
+	page := exportPage{Name: "1.jpg", MediaType: "image/jpeg", Width: 50, Height: 60, Data: jpegData.Bytes()}
+	buf.Reset()
+	err := (&pdfDocument{Chapters: []exportChapter{
+		{Title: "First", Pages: []exportPage{page}},
+		{Title: "Empty"},
+		{Title: "Last", Pages: []exportPage{page}},
+	}}).write(&buf)
+	if err != nil {
+		t.Fatalf("write pdf: %s", err)
+	}
+	doc = buf.String()
+	for _, want := range []string{
+		"/Type /Outlines /First 13 0 R /Last 14 0 R /Count 2",
+		"/Title " + pdfString("First") + " /Parent 3 0 R /Dest [5 0 R /Fit] /Next 14 0 R >>",
+		"/Title " + pdfString("Last") + " /Parent 3 0 R /Dest [9 0 R /Fit] /Prev 13 0 R >>",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document missing %s", want)
+		}
+	}
+	if strings.Contains(doc, pdfString("Empty")) {
+		t.Error("empty chapter has an outline item")
+	}
+}
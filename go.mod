@@ -1,5 +1,5 @@
 module github.com/KidEkko/mangodex
 
-go 1.22.4
+go 1.23
 
 require github.com/google/go-querystring v1.1.0
@@ -0,0 +1,178 @@
+package mangodex
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"time"
+)
+
+// MaxOffset : Upper bound of offset + limit accepted by the list endpoints of the API.
+const MaxOffset = 10000
+
+const (
+	defaultPageLimit = 100
+	sinceLayout      = "2006-01-02T15:04:05"
+)
+
+// ErrOffsetCeiling : Returned by iterators that cannot page past MaxOffset.
+// Iterators ordered by ascending creation time avoid this by windowing with createdAtSince.
+var ErrOffsetCeiling = errors.New("results exceed the offset limit of the API")
+
+// CollectAll : Collect all values of an iterator, stopping at the first error.
+func CollectAll[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var all []T
+	for v, err := range seq {
+		if err != nil {
+			return all, err
+		}
+		all = append(all, v)
+	}
+	return all, nil
+}
+
+// pager : Pages through a list endpoint.
+type pager[T any] struct {
+	limit int
+	// fetch : Get a page of results, only including ones created since the given time if it is not empty.
+	fetch func(ctx context.Context, offset, limit int, since string) (items []T, total int, err error)
+	// key : Get the ID and creation time of a result. Only set when results are ordered by ascending creation time,
+	// which allows paging past MaxOffset.
+	key func(T) (id, createdAt string)
+}
+
+// all : Iterate over all results, until an error occurs or the context is done.
+func (p *pager[T]) all(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		limit := p.limit
+		if limit <= 0 {
+			limit = defaultPageLimit
+		}
+
+		offset, since, last := 0, "", ""
+		// IDs of results created at the last seen creation time, which appear again in the next window.
+		seen, boundary := map[string]bool{}, map[string]bool{}
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			// Start a new window once the offset limit is reached.
+			if offset+limit > MaxOffset {
+				next := toSince(last)
+				if p.key == nil || next == "" || next == since {
+					yield(zero, ErrOffsetCeiling)
+					return
+				}
+				offset, since, seen = 0, next, boundary
+			}
+
+			items, total, err := p.fetch(ctx, offset, limit, since)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range items {
+				if p.key != nil {
+					id, createdAt := p.key(item)
+					if seen[id] {
+						continue
+					}
+					if createdAt != last {
+						last, boundary = createdAt, map[string]bool{}
+					}
+					boundary[id] = true
+				}
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			offset += len(items)
+			if len(items) == 0 || offset >= total {
+				return
+			}
+		}
+	}
+}
+
+// toSince : Convert a creation time from the API to the format of createdAtSince parameters.
+func toSince(createdAt string) string {
+	t, err := time.Parse(time.RFC3339, createdAt)
+	if err != nil {
+		return ""
+	}
+	return t.UTC().Format(sinceLayout)
+}
+
+// GetMangaListIter : Iterate over all Manga matching params, fetching pages as required.
+// Limit is used as the page size, and Offset is ignored. Unless another order is set, Manga are ordered by
+// ascending creation time, so that results past MaxOffset can be fetched.
+func (s *MangaService) GetMangaListIter(ctx context.Context, params *ListMangaParams) iter.Seq2[Manga, error] {
+	var p ListMangaParams
+	if params != nil {
+		p = *params
+	}
+	if p.Order == (MangaOrder{}) {
+		p.Order.Created = AscendingOrder
+	}
+
+	pg := &pager[Manga]{
+		limit: p.Limit,
+		fetch: func(ctx context.Context, offset, limit int, since string) ([]Manga, int, error) {
+			p.Offset, p.Limit = offset, limit
+			if since != "" {
+				p.CreatedSince = since
+			}
+			l, err := s.GetMangaListContext(ctx, &p)
+			return l.Data, l.Total, err
+		},
+	}
+	if p.Order == (MangaOrder{Created: AscendingOrder}) {
+		pg.key = func(m Manga) (string, string) { return m.ID, m.Attributes.CreatedAt }
+	}
+	return pg.all(ctx)
+}
+
+// GetMangaChaptersIter : Iterate over all chapters of a manga matching params, fetching pages as required.
+// Limit is used as the page size, and Offset is ignored. Unless another order is set, chapters are ordered by
+// ascending creation time, so that results past MaxOffset can be fetched.
+func (s *ChapterService) GetMangaChaptersIter(ctx context.Context, id string, params *ListChapterParams) iter.Seq2[Chapter, error] {
+	var p ListChapterParams
+	if params != nil {
+		p = *params
+	}
+	if p.Order == (ChapterOrder{}) {
+		p.Order.Created = AscendingOrder
+	}
+
+	pg := &pager[Chapter]{
+		limit: p.Limit,
+		fetch: func(ctx context.Context, offset, limit int, since string) ([]Chapter, int, error) {
+			p.Offset, p.Limit = offset, limit
+			if since != "" {
+				p.CreatedSince = since
+			}
+			l, err := s.GetMangaChaptersContext(ctx, id, &p)
+			return l.Data, l.Total, err
+		},
+	}
+	if p.Order == (ChapterOrder{Created: AscendingOrder}) {
+		pg.key = func(c Chapter) (string, string) { return c.ID, c.Attributes.CreatedAt }
+	}
+	return pg.all(ctx)
+}
+
+// GetUserFollowedMangaListIter : Iterate over all Manga followed by the logged in user, fetching pages as required.
+func (s *UserService) GetUserFollowedMangaListIter(ctx context.Context, includes []string) iter.Seq2[Manga, error] {
+	pg := &pager[Manga]{
+		fetch: func(ctx context.Context, offset, limit int, _ string) ([]Manga, int, error) {
+			l, err := s.GetUserFollowedMangaListContext(ctx, limit, offset, includes)
+			return l.Data, l.Total, err
+		},
+	}
+	return pg.all(ctx)
+}
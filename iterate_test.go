@@ -0,0 +1,87 @@
+package mangodex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGetMangaListIter(t *testing.T) {
+	// Fake list endpoint with more results than the offset limit, three created every second.
+	const n = MaxOffset + 250
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdAt := func(i int) time.Time { return base.Add(time.Duration(i/3) * time.Second) }
+
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		if offset+limit > MaxOffset {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if q.Get("order[createdAt]") != AscendingOrder {
+			t.Errorf("expected ascending creation order, got %v", q)
+		}
+
+		first := 0
+		if since := q.Get("createdAtSince"); since != "" {
+			s, _ := time.Parse(sinceLayout, since)
+			for first < n && createdAt(first).Before(s) {
+				first++
+			}
+		}
+		l := MangaList{CommonResponse: CommonResponse{Result: "ok", Total: n - first}}
+		for i := first + offset; i < n && i < first+offset+limit; i++ {
+			m := Manga{ID: strconv.Itoa(i)}
+			m.Attributes.CreatedAt = createdAt(i).Format(time.RFC3339)
+			l.Data = append(l.Data, m)
+		}
+		json.NewEncoder(w).Encode(&l)
+	}))
+
+	i := 0
+	for m, err := range c.Manga.GetMangaListIter(context.Background(), nil) {
+		if err != nil {
+			t.Fatalf("iterate: %s", err)
+		}
+		if m.ID != strconv.Itoa(i) {
+			t.Fatalf("expected manga %d, got %s", i, m.ID)
+		}
+		i++
+	}
+	if i != n {
+		t.Errorf("expected %d manga, got %d", n, i)
+	}
+
+	// An order that cannot be windowed stops at the offset limit.
+	_, err := CollectAll(c.Manga.GetMangaListIter(context.Background(), &ListMangaParams{
+		Order: MangaOrder{Created: AscendingOrder, Title: AscendingOrder},
+	}))
+	if err != ErrOffsetCeiling {
+		t.Errorf("expected ErrOffsetCeiling, got %v", err)
+	}
+}
+
+func TestIterCancel(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"result":"ok","total":1000,"data":[{"id":"a"},{"id":"b"}]}`)
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	got, err := CollectAll(func(yield func(Chapter, error) bool) {
+		for ch, err := range c.Chapter.GetMangaChaptersIter(ctx, "abc", nil) {
+			if !yield(ch, err) {
+				return
+			}
+			cancel()
+		}
+	})
+	if err != context.Canceled || len(got) != 2 {
+		t.Errorf("expected cancellation after first page, got %d chapters, %v", len(got), err)
+	}
+}
@@ -0,0 +1,170 @@
+package mangodex
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WithLogger : Log requests to the API and page fetches from MangaDex@Home to logger. Successful requests are
+// logged at debug level, and failed ones at warning level. Request headers and bodies are never logged, so
+// credentials stay out of logs. MangaDex@Home servers are only logged by host, as their URLs contain a token.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *DexClient) {
+		c.logger = logger
+	}
+}
+
+// rateLimitHeaders : Response headers logged with every request, with their log keys.
+var rateLimitHeaders = [][2]string{
+	{"X-RateLimit-Limit", "ratelimit_limit"},
+	{"X-RateLimit-Remaining", "ratelimit_remaining"},
+	{"X-RateLimit-Retry-After", "ratelimit_retry_after"},
+}
+
+// logRequest : Log an attempt of a request to the API. resp is nil if no response was received.
+func (c *DexClient) logRequest(ctx context.Context, ar *apiRequest, resp *http.Response, err error, elapsed time.Duration) {
+	if c.logger == nil {
+		return
+	}
+
+	path := ar.route
+	if path == "" {
+		if u, perr := url.Parse(ar.url); perr == nil {
+			path = u.Path
+		}
+	}
+	attrs := []slog.Attr{
+		slog.String("method", ar.method),
+		slog.String("path", path),
+		slog.String("endpoint", ar.endpoint),
+		slog.Int("attempt", ar.attempt),
+		slog.Duration("duration", elapsed),
+	}
+
+	level := slog.LevelDebug
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		for _, h := range rateLimitHeaders {
+			if v := resp.Header.Get(h[0]); v != "" {
+				attrs = append(attrs, slog.String(h[1], v))
+			}
+		}
+		if resp.StatusCode >= http.StatusBadRequest {
+			level = slog.LevelWarn
+		}
+	}
+	if err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	c.logger.LogAttrs(ctx, level, "api request", attrs...)
+}
+
+// logRetry : Log that a failed request is sent again after delay.
+func (c *DexClient) logRetry(ctx context.Context, ar *apiRequest, delay time.Duration) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "retrying api request",
+		slog.String("method", ar.method),
+		slog.String("endpoint", ar.endpoint),
+		slog.Int("attempt", ar.attempt),
+		slog.Duration("delay", delay))
+}
+
+// logCacheHit : Log a request answered from the response cache.
+func (c *DexClient) logCacheHit(ctx context.Context, ar *apiRequest) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "api request served from cache",
+		slog.String("method", ar.method),
+		slog.String("path", ar.route),
+		slog.String("endpoint", ar.endpoint))
+}
+
+// logCacheError : Log a failure to update the response cache.
+func (c *DexClient) logCacheError(ctx context.Context, err error) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.LogAttrs(ctx, slog.LevelWarn, "updating response cache failed", slog.String("error", err.Error()))
+}
+
+// logTokenStoreError : Log a failure to save refreshed tokens to the TokenStore.
+func (c *DexClient) logTokenStoreError(ctx context.Context, err error) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.LogAttrs(ctx, slog.LevelWarn, "saving refreshed tokens failed", slog.String("error", err.Error()))
+}
+
+// logPage : Log an attempt to fetch a page from MangaDex@Home.
+func (c *MDHomeClient) logPage(ctx context.Context, src PageSource, attempt int, err error, elapsed time.Duration) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("host", nodeHost(src.BaseURL)),
+		slog.String("chapter", c.chapterID),
+		slog.String("quality", src.Quality),
+		slog.String("filename", src.Filename),
+		slog.Int("attempt", attempt),
+		slog.Duration("duration", elapsed),
+	}
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelWarn
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			attrs = append(attrs, slog.Int("status", apiErr.StatusCode))
+		}
+		attrs = append(attrs, slog.String("error", redactNode(err.Error(), src.BaseURL)))
+	}
+	c.logger.LogAttrs(ctx, level, "page fetch", attrs...)
+}
+
+// logPageCacheHit : Log a page served from the PageCache.
+func (c *MDHomeClient) logPageCacheHit(ctx context.Context, filename string) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "page served from cache",
+		slog.String("chapter", c.chapterID),
+		slog.String("quality", c.quality),
+		slog.String("filename", filename))
+}
+
+// logNodeChange : Log that a failing server was replaced.
+func (c *MDHomeClient) logNodeChange(ctx context.Context, from, to string) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.LogAttrs(ctx, slog.LevelInfo, "replaced MangaDex@Home server",
+		slog.String("chapter", c.chapterID),
+		slog.String("from", nodeHost(from)),
+		slog.String("to", nodeHost(to)))
+}
+
+// nodeHost : Get the host of a MangaDex@Home base URL, leaving out the token in its path.
+func nodeHost(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// redactNode : Replace the base URL of a server in msg with its host, such as in network errors.
+func redactNode(msg, baseURL string) string {
+	if baseURL == "" {
+		return msg
+	}
+	return strings.ReplaceAll(msg, baseURL, nodeHost(baseURL))
+}
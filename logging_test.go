@@ -0,0 +1,103 @@
+package mangodex
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logEntries : Decode the JSON log lines written to buf.
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e map[string]any
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("invalid log line %q: %s", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestRequestLogging(t *testing.T) {
+	var attempts int
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("X-RateLimit-Limit", "5")
+		w.Header().Set("X-RateLimit-Remaining", "4")
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"result":"ok","data":{"id":"abc"}}`))
+	}), WithLogger(logger), WithRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}))
+	c.creds.set("secret-session", "secret-refresh")
+
+	if _, err := c.Manga.GetManga("abc", nil); err != nil {
+		t.Fatalf("get manga: %s", err)
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("credentials logged: %s", buf.String())
+	}
+
+	entries := logEntries(t, &buf)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 log entries, got %d: %s", len(entries), buf.String())
+	}
+	failed, retry, ok := entries[0], entries[1], entries[2]
+	if failed["level"] != "WARN" || failed["status"] != 503.0 || failed["attempt"] != 1.0 {
+		t.Errorf("unexpected entry for failed attempt %v", failed)
+	}
+	if retry["msg"] != "retrying api request" || retry["attempt"] != 1.0 {
+		t.Errorf("unexpected retry entry %v", retry)
+	}
+	if ok["level"] != "DEBUG" || ok["method"] != "GET" || ok["path"] != MangaPath || ok["endpoint"] != "manga.get" ||
+		ok["status"] != 200.0 || ok["attempt"] != 2.0 || ok["ratelimit_remaining"] != "4" || ok["duration"] == nil {
+		t.Errorf("unexpected entry for request %v", ok)
+	}
+}
+
+func TestPageLogging(t *testing.T) {
+	f, _ := newFakeAtHome(t, 1)
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	c := NewDexClient(WithBaseURL(f.srv.URL), WithReportURL(f.srv.URL+"/report"), WithLogger(logger))
+
+	mc, err := c.AtHome.NewMDHomeClient("chapter", "data", false)
+	if err != nil {
+		t.Fatalf("new client: %s", err)
+	}
+	mc.FailoverThreshold = 1
+	f.broken["node0"] = true
+	if _, err = mc.GetChapterPage(mc.Pages[0]); err != nil {
+		t.Fatalf("get page: %s", err)
+	}
+	if strings.Contains(buf.String(), "/node") {
+		t.Errorf("server token logged: %s", buf.String())
+	}
+
+	entries := logEntries(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 log entries, got %d: %s", len(entries), buf.String())
+	}
+	host := nodeHost(f.srv.URL)
+	if e := entries[0]; e["msg"] != "page fetch" || e["status"] != 502.0 || e["host"] != host || e["filename"] != "p0-abc.png" {
+		t.Errorf("unexpected entry for failed fetch %v", e)
+	}
+	if e := entries[1]; e["msg"] != "replaced MangaDex@Home server" || e["from"] != host {
+		t.Errorf("unexpected entry for failover %v", e)
+	}
+
+	err = errors.New(`Get "https://node.example/token/data/hash/p0.png": EOF`)
+	if got := redactNode(err.Error(), "https://node.example/token"); got != `Get "node.example/data/hash/p0.png": EOF` {
+		t.Errorf("unexpected redacted error %q", got)
+	}
+}
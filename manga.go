@@ -4,14 +4,13 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"net/url"
-	"strings"
 )
 
 const (
 	MangaPath                = "manga/%s"
 	MangaAggregatePath       = "manga/%s/aggregate"
 	MangaListPath            = "manga"
+	MangaTagPath             = "manga/tag"
 	CheckIfMangaFollowedPath = "user/follows/manga/%s"
 	ToggleMangaFollowPath    = "manga/%s/follow"
 )
@@ -119,8 +118,7 @@ func (s *MangaService) GetMangaList(params *ListMangaParams) (*MangaList, error)
 
 // GetMangaListContext : GetMangaList with custom context.
 func (s *MangaService) GetMangaListContext(ctx context.Context, params *ListMangaParams) (*MangaList, error) {
-	u, _ := url.Parse(BaseAPI)
-	u.Path = MangaListPath
+	u := s.client.apiURL(MangaListPath)
 
 	// Set query parameters
 	u.RawQuery = EncodeParams(params)
@@ -148,8 +146,7 @@ func (s *MangaService) GetManga(id string, params *GetMangaParams) (*SingleManga
 }
 
 func (s *MangaService) GetMangaWithContext(ctx context.Context, id string, params *GetMangaParams) (*SingleManga, error) {
-	u, _ := url.Parse(BaseAPI)
-	u.Path = fmt.Sprintf(MangaPath, id)
+	u := s.client.apiURL(fmt.Sprintf(MangaPath, id))
 
 	u.RawQuery = EncodeParams(params)
 
@@ -204,8 +201,7 @@ func (s *MangaService) GetMangaAggregate(mangaId string, params *MangaAggregateP
 
 // GetMangaListContext : GetMangaList with custom context.
 func (s *MangaService) GetMangaAggregateContext(ctx context.Context, mangaId string, params *MangaAggregateParams) (*MangaAggregate, error) {
-	u, _ := url.Parse(BaseAPI)
-	u.Path = fmt.Sprintf(MangaAggregatePath, mangaId)
+	u := s.client.apiURL(fmt.Sprintf(MangaAggregatePath, mangaId))
 
 	// Set query parameters
 	u.RawQuery = EncodeParams(params)
@@ -222,13 +218,12 @@ func (s *MangaService) CheckIfMangaFollowed(id string) (bool, error) {
 
 // CheckIfMangaFollowedContext : CheckIfMangaFollowed with custom context.
 func (s *MangaService) CheckIfMangaFollowedContext(ctx context.Context, id string) (bool, error) {
-	u, _ := url.Parse(BaseAPI)
-	u.Path = fmt.Sprintf(CheckIfMangaFollowedPath, id)
+	u := s.client.apiURL(fmt.Sprintf(CheckIfMangaFollowedPath, id))
 
 	var r Response
 	err := s.client.RequestAndDecode(ctx, http.MethodGet, u.String(), nil, &r)
 	if err != nil {
-		if strings.Contains(err.Error(), "404") {
+		if IsNotFound(err) {
 			return false, nil
 		}
 		return false, err
@@ -243,8 +238,7 @@ func (s *MangaService) ToggleMangaFollowStatus(id string, toFollow bool) (*Respo
 
 // ToggleMangaFollowStatusContext  ToggleMangaFollowStatus with custom context.
 func (s *MangaService) ToggleMangaFollowStatusContext(ctx context.Context, id string, toFollow bool) (*Response, error) {
-	u, _ := url.Parse(BaseAPI)
-	u.Path = fmt.Sprintf(ToggleMangaFollowPath, id)
+	u := s.client.apiURL(fmt.Sprintf(ToggleMangaFollowPath, id))
 
 	method := http.MethodPost // To follow
 	if !toFollow {
@@ -252,6 +246,34 @@ func (s *MangaService) ToggleMangaFollowStatusContext(ctx context.Context, id st
 	}
 
 	var r Response
-	err := s.client.RequestAndDecode(ctx, method, u.String(), nil, &r)
-	return &r, err
+	if err := s.client.RequestAndDecode(ctx, method, u.String(), nil, &r); err != nil {
+		return &r, err
+	}
+	s.client.dropCached(ctx, fmt.Sprintf(CheckIfMangaFollowedPath, id), GetUserFollowedMangaListPath)
+	return &r, nil
+}
+
+// TagList : A response for getting the list of tags.
+type TagList struct {
+	CommonResponse
+	Data []Tag `json:"data"`
+}
+
+func (tl *TagList) GetResult() string {
+	return tl.Result
+}
+
+// GetMangaTags : Get all tags that can be given to manga.
+// https://api.mangadex.org/docs/redoc.html#tag/Manga/operation/get-manga-tag
+func (s *MangaService) GetMangaTags() (*TagList, error) {
+	return s.GetMangaTagsContext(context.Background())
+}
+
+// GetMangaTagsContext : GetMangaTags with custom context.
+func (s *MangaService) GetMangaTagsContext(ctx context.Context) (*TagList, error) {
+	u := s.client.apiURL(MangaTagPath)
+
+	var l TagList
+	err := s.client.RequestAndDecode(ctx, http.MethodGet, u.String(), nil, &l)
+	return &l, err
 }
@@ -0,0 +1,140 @@
+package mangodex
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ManifestName : Name of the manifest written next to the pages by Downloader.DownloadToDir.
+const ManifestName = "manifest.json"
+
+// Manifest : Record of a chapter download, used to resume it without fetching finished pages again.
+type Manifest struct {
+	ChapterID string `json:"chapterId"`
+	// Hash : Hash of the chapter on MangaDex@Home, which changes when the chapter is re-uploaded.
+	Hash    string `json:"hash"`
+	Quality string `json:"quality"`
+	// Pages : Finished pages, keyed by their MangaDex@Home filename.
+	Pages map[string]ManifestPage `json:"pages"`
+}
+
+// ManifestPage : A finished page of a download.
+type ManifestPage struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// LoadManifest : Load the manifest of a download in dir. Returns nil if there is none.
+func LoadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err = json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// manifestWriter : Keeps the manifest of a download in sync with the pages written.
+type manifestWriter struct {
+	mu       sync.Mutex
+	dir      string
+	manifest *Manifest
+}
+
+// openManifest : Load the manifest in dir for the chapter of client. If the chapter was re-uploaded or downloaded
+// in another quality since, the pages of the previous download are removed and a new manifest is started.
+func openManifest(dir string, client *MDHomeClient) (*manifestWriter, error) {
+	old, err := LoadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		ChapterID: client.chapterID,
+		Hash:      client.hash,
+		Quality:   client.quality,
+		Pages:     map[string]ManifestPage{},
+	}
+	if old != nil && old.ChapterID == m.ChapterID && old.Hash == m.Hash && old.Quality == m.Quality && old.Pages != nil {
+		m.Pages = old.Pages
+	} else if old != nil {
+		for _, p := range old.Pages {
+			// Names come from a file on disk, so only pages directly in dir are removed.
+			if !filepath.IsLocal(p.Name) || filepath.Base(p.Name) != p.Name {
+				continue
+			}
+			if err = os.Remove(filepath.Join(dir, p.Name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+		}
+	}
+	return &manifestWriter{dir: dir, manifest: m}, nil
+}
+
+// finished : Check whether a page was finished by a previous download and is still intact on disk.
+func (mw *manifestWriter) finished(r *PageResult) bool {
+	mw.mu.Lock()
+	p, ok := mw.manifest.Pages[r.Filename]
+	mw.mu.Unlock()
+	if !ok || p.Name != r.Name {
+		return false
+	}
+
+	size, sum, err := fileChecksum(filepath.Join(mw.dir, p.Name))
+	if err != nil || size != p.Size || sum != p.SHA256 {
+		return false
+	}
+	r.Bytes = p.Size
+	return true
+}
+
+// add : Record a finished page of size bytes with the hex SHA-256 sum, and save the manifest.
+func (mw *manifestWriter) add(r *PageResult, size int64, sum string) error {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	mw.manifest.Pages[r.Filename] = ManifestPage{
+		Name:   r.Name,
+		SHA256: sum,
+		Size:   size,
+	}
+	return mw.save()
+}
+
+// save : Write the manifest to disk. Must be called with mu held.
+func (mw *manifestWriter) save() error {
+	data, err := json.MarshalIndent(mw.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(mw.dir, ManifestName), data, 0o644)
+}
+
+// fileChecksum : Get the size and hex SHA-256 of the file at path, without reading it into memory.
+func fileChecksum(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return n, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
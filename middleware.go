@@ -0,0 +1,40 @@
+package mangodex
+
+import (
+	"context"
+	"net/http"
+)
+
+// RoundTripFunc : Sends a single HTTP request to the API.
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware : Wraps the sending of requests, for example to add tracing, metrics or headers.
+// The name of the endpoint being requested is available from the request context with EndpointFromContext.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// WithMiddleware : Send API requests through middleware. The first middleware is the outermost one,
+// seeing requests first and responses last. Middleware is called for every attempt of a request,
+// but not for responses served from the response cache.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *DexClient) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// endpointKey : Context key of the endpoint name.
+type endpointKey struct{}
+
+// EndpointFromContext : Get the name of the endpoint being requested, such as "manga.list" or "chapter.feed".
+// Returns an empty string for requests to unknown endpoints.
+func EndpointFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(endpointKey{}).(string)
+	return name
+}
+
+// chainMiddleware : Wrap next in middleware, with the first middleware outermost.
+func chainMiddleware(next RoundTripFunc, middleware []Middleware) RoundTripFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		next = middleware[i](next)
+	}
+	return next
+}
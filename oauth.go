@@ -0,0 +1,158 @@
+package mangodex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	OAuthTokenURL = "https://auth.mangadex.org/realms/mangadex/protocol/openid-connect/token"
+)
+
+// ErrNoOAuthClient : Returned when an OAuth2 flow is used without configuring a personal API client.
+var ErrNoOAuthClient = errors.New("no personal API client configured, use WithOAuthClient")
+
+// WithOAuthClient : Set the personal API client used for the OAuth2 login flow.
+// https://api.mangadex.org/docs/02-authentication/personal-clients/
+func WithOAuthClient(clientID, clientSecret string) Option {
+	return func(c *DexClient) {
+		c.oauthClientID = clientID
+		c.oauthClientSecret = clientSecret
+	}
+}
+
+// WithAuthURL : Use a different OAuth2 token endpoint.
+func WithAuthURL(tokenURL string) Option {
+	return func(c *DexClient) {
+		c.authURL = tokenURL
+	}
+}
+
+// OAuthToken : Tokens issued by the MangaDex auth server.
+type OAuthToken struct {
+	AccessToken      string
+	RefreshToken     string
+	TokenType        string
+	ExpiresAt        time.Time
+	RefreshExpiresAt time.Time
+}
+
+// oauthResponse : Response of the OAuth2 token endpoint.
+type oauthResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+}
+
+// oauthError : Error response of the OAuth2 token endpoint.
+type oauthError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// PasswordLogin : Login with the OAuth2 password grant, using the personal API client set with WithOAuthClient.
+// https://api.mangadex.org/docs/02-authentication/personal-clients/
+func (s *AuthService) PasswordLogin(user, pwd string) (*OAuthToken, error) {
+	return s.PasswordLoginContext(context.Background(), user, pwd)
+}
+
+// PasswordLoginContext : PasswordLogin with custom context.
+func (s *AuthService) PasswordLoginContext(ctx context.Context, user, pwd string) (*OAuthToken, error) {
+	form := url.Values{}
+	form.Set("grant_type", "password")
+	form.Set("username", user)
+	form.Set("password", pwd)
+	t, err := s.requestOAuthToken(ctx, form)
+	if err != nil {
+		return nil, err
+	}
+	s.client.userChanged(ctx)
+	return t, s.client.persistTokens()
+}
+
+// RefreshOAuthToken : Get a new access token with the OAuth2 refresh grant.
+func (s *AuthService) RefreshOAuthToken() (*OAuthToken, error) {
+	return s.RefreshOAuthTokenContext(context.Background())
+}
+
+// RefreshOAuthTokenContext : RefreshOAuthToken with custom context.
+func (s *AuthService) RefreshOAuthTokenContext(ctx context.Context) (*OAuthToken, error) {
+	_, refresh := s.client.creds.get()
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refresh)
+	t, err := s.requestOAuthToken(ctx, form)
+	if err != nil {
+		return nil, err
+	}
+	s.client.saveRefreshedTokens(ctx)
+	return t, nil
+}
+
+// requestOAuthToken : Request tokens from the token endpoint and set them in the client, without saving them.
+func (s *AuthService) requestOAuthToken(ctx context.Context, form url.Values) (*OAuthToken, error) {
+	if s.client.oauthClientID == "" {
+		return nil, ErrNoOAuthClient
+	}
+	form.Set("client_id", s.client.oauthClientID)
+	form.Set("client_secret", s.client.oauthClientSecret)
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.client.do(ctx, &apiRequest{
+		method:    http.MethodPost,
+		url:       s.client.authURL,
+		endpoint:  oauthEndpoint,
+		payload:   []byte(form.Encode()),
+		header:    header,
+		anonymous: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var or oauthResponse
+	if err = json.NewDecoder(resp.Body).Decode(&or); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	t := &OAuthToken{
+		AccessToken:  or.AccessToken,
+		RefreshToken: or.RefreshToken,
+		TokenType:    or.TokenType,
+		ExpiresAt:    now.Add(time.Duration(or.ExpiresIn) * time.Second),
+	}
+	if or.RefreshExpiresIn > 0 {
+		t.RefreshExpiresAt = now.Add(time.Duration(or.RefreshExpiresIn) * time.Second)
+	}
+
+	// Keep the previous refresh token if the server did not rotate it.
+	if t.RefreshToken == "" {
+		_, t.RefreshToken = s.client.creds.get()
+	}
+	s.client.creds.setOAuth(t.AccessToken, t.RefreshToken, t.ExpiresAt)
+	return t, nil
+}
+
+// decodeOAuthError : Convert an OAuth2 error body into an Error, if it is one.
+func decodeOAuthError(data []byte, status int) (Error, bool) {
+	var oe oauthError
+	if err := json.Unmarshal(data, &oe); err != nil || oe.Error == "" {
+		return Error{}, false
+	}
+	return Error{
+		Status: status,
+		Title:  oe.Error,
+		Detail: strings.TrimSpace(oe.Description),
+	}, true
+}
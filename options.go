@@ -0,0 +1,69 @@
+package mangodex
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Option : Configures a DexClient when passed to NewDexClient.
+type Option func(*DexClient)
+
+// ErrInvalidBaseURL : Returned by every request of a client created with an invalid base URL.
+var ErrInvalidBaseURL = errors.New("invalid base URL")
+
+// WithBaseURL : Use a different API base URL, such as a staging mirror or a local fake.
+// The URL must be an absolute http or https URL. Otherwise all requests of the client fail with ErrInvalidBaseURL.
+func WithBaseURL(baseURL string) Option {
+	return func(c *DexClient) {
+		u, err := parseBaseURL(baseURL)
+		if err != nil {
+			c.configErr = err
+			return
+		}
+		c.baseURL = u
+	}
+}
+
+// parseBaseURL : Parse an absolute http or https base URL, dropping trailing slashes.
+func parseBaseURL(baseURL string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidBaseURL, baseURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w %q: not an absolute http(s) URL", ErrInvalidBaseURL, baseURL)
+	}
+	return u, nil
+}
+
+// WithHTTPClient : Use a custom http.Client, for example to set timeouts or a custom transport.
+// The client is also used by MDHomeClient to fetch pages.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *DexClient) {
+		if client != nil {
+			c.client = client
+		}
+	}
+}
+
+// WithUserAgent : Set the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return WithHeader("User-Agent", userAgent)
+}
+
+// WithHeader : Set a default header sent with every request.
+func WithHeader(key, value string) Option {
+	return func(c *DexClient) {
+		c.header.Set(key, value)
+	}
+}
+
+// WithReportURL : Use a different endpoint for MangaDex@Home reports.
+func WithReportURL(reportURL string) Option {
+	return func(c *DexClient) {
+		c.reportURL = reportURL
+	}
+}
@@ -0,0 +1,105 @@
+package mangodex
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// PageStream : A page being fetched from MangaDex@Home.
+type PageStream struct {
+	// ContentType : Content type of the page, such as "image/png".
+	ContentType string
+	// ContentLength : Size of the page in bytes, or -1 if unknown.
+	ContentLength int64
+	// Source : Where the page is fetched from.
+	Source PageSource
+
+	body     io.ReadCloser
+	digest   hash.Hash
+	want     string
+	reporter *reporter
+	report   *reportPayload
+	start    time.Time
+	once     sync.Once
+
+	// cache : Receives the page as it is read. The page is added to the cache on Close,
+	// if it was read successfully.
+	cache PageWriter
+}
+
+// newCachedPageStream : Create a stream of a page that was already fetched. It is not reported to MangaDex@Home.
+func newCachedPageStream(src PageSource, data []byte) *PageStream {
+	return &PageStream{
+		ContentType:   http.DetectContentType(data),
+		ContentLength: int64(len(data)),
+		Source:        src,
+		body:          io.NopCloser(bytes.NewReader(data)),
+		report:        newPayload(""),
+		start:         time.Now(),
+	}
+}
+
+// verify : Check the page against the hash in its filename once it has been read, if it has one.
+func (ps *PageStream) verify() {
+	if want, ok := pageHash(ps.Source.Filename); ok {
+		ps.digest, ps.want = sha256.New(), want
+	}
+}
+
+// Read : Read the page. With verification enabled, reading the end of a page that does not match
+// its hash returns ErrPageHashMismatch instead of io.EOF.
+func (ps *PageStream) Read(p []byte) (int, error) {
+	n, err := ps.body.Read(p)
+	ps.report.Bytes += n
+	if ps.digest != nil {
+		ps.digest.Write(p[:n])
+	}
+	// The cache only saves fetches, so failing to add a page does not fail the fetch.
+	if ps.cache != nil {
+		if _, werr := ps.cache.Write(p[:n]); werr != nil {
+			ps.cache.Abort()
+			ps.cache = nil
+		}
+	}
+
+	if err == io.EOF {
+		ps.report.Success = true
+		if ps.digest != nil && !matchesPageHash(ps.want, ps.digest.Sum(nil)) {
+			ps.report.Success = false
+			err = fmt.Errorf("%w: %s", ErrPageHashMismatch, ps.Source.Filename)
+		}
+	} else if err != nil {
+		ps.report.Success = false
+	}
+	return n, err
+}
+
+// Close : Stop fetching the page, and report the result. The fetch only counts as successful if the page
+// was read to the end, and only successful fetches are added to the PageCache.
+func (ps *PageStream) Close() error {
+	err := ps.body.Close()
+	if ps.cache != nil {
+		if ps.report.Success {
+			ps.cache.Commit()
+		} else {
+			ps.cache.Abort()
+		}
+		ps.cache = nil
+	}
+	ps.sendReport()
+	return err
+}
+
+// sendReport : Queue the report for the fetch, only once.
+func (ps *PageStream) sendReport() {
+	ps.once.Do(func() {
+		ps.report.Duration = time.Since(ps.start).Milliseconds()
+		ps.reporter.submit(*ps.report)
+	})
+}
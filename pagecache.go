@@ -0,0 +1,314 @@
+package mangodex
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PageKey : Identifies a page in a PageCache.
+type PageKey struct {
+	// Hash : Hash of the chapter upload, from ChaptersData.Hash.
+	Hash    string
+	Quality string
+	// Filename : Filename of the page on MangaDex@Home.
+	Filename string
+}
+
+// PageCache : Stores pages fetched from MangaDex@Home, so that they are not fetched again.
+// Caches must be safe for concurrent use. Streamed pages are held in memory until they can be added,
+// unless the cache also implements PageCacheWriter.
+type PageCache interface {
+	// Get : Get a cached page, and whether it was found.
+	Get(key PageKey) ([]byte, bool)
+	// Put : Add a page to the cache.
+	Put(key PageKey, data []byte) error
+}
+
+// PageCacheWriter : Optionally implemented by a PageCache to add pages while they are being streamed.
+type PageCacheWriter interface {
+	// NewPageWriter : Start adding a page.
+	NewPageWriter(key PageKey) (PageWriter, error)
+}
+
+// PageWriter : A page being added to a PageCache. The page is only added once Commit is called.
+type PageWriter interface {
+	io.Writer
+	// Commit : Add the written page to the cache.
+	Commit() error
+	// Abort : Discard the written page.
+	Abort() error
+}
+
+// WithPageCache : Look up pages in cache before fetching them from MangaDex@Home, and add fetched pages to it.
+func WithPageCache(cache PageCache) Option {
+	return func(c *DexClient) {
+		c.pageCache = cache
+	}
+}
+
+// newPageWriter : Start adding a page to cache, or return nil if that is not possible.
+func newPageWriter(cache PageCache, key PageKey) PageWriter {
+	if cw, ok := cache.(PageCacheWriter); ok {
+		w, err := cw.NewPageWriter(key)
+		if err != nil {
+			return nil
+		}
+		return w
+	}
+	return &bufferedPageWriter{cache: cache, key: key}
+}
+
+// bufferedPageWriter : PageWriter for caches that can only add whole pages.
+type bufferedPageWriter struct {
+	cache PageCache
+	key   PageKey
+	buf   bytes.Buffer
+}
+
+func (w *bufferedPageWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *bufferedPageWriter) Commit() error {
+	return w.cache.Put(w.key, w.buf.Bytes())
+}
+
+func (w *bufferedPageWriter) Abort() error {
+	return nil
+}
+
+const (
+	// evictTarget : Fraction of its limit a DiskPageCache is reduced to when evicting, so that the directory
+	// is not scanned again on the next put.
+	evictTarget = 0.9
+	// staleTempAge : Age after which temporary files left by interrupted writes are removed.
+	staleTempAge = time.Hour
+)
+
+// DiskPageCache : PageCache storing pages as files in a directory, which can be shared by several processes.
+// Once the cache grows larger than its limit, the least recently used pages are removed in the background.
+type DiskPageCache struct {
+	dir      string
+	maxBytes int64
+
+	mu        sync.Mutex
+	size      int64
+	evicting  bool
+	evictions sync.WaitGroup
+}
+
+// NewDiskPageCache : Create a DiskPageCache in dir, which is created if required, holding at most maxBytes of pages.
+func NewDiskPageCache(dir string, maxBytes int64) (*DiskPageCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	d := &DiskPageCache{dir: dir, maxBytes: maxBytes}
+
+	files, err := d.files()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		d.size += f.size
+	}
+	return d, nil
+}
+
+// Get : Get a cached page, marking it as recently used.
+func (d *DiskPageCache) Get(key PageKey) ([]byte, bool) {
+	path := d.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return data, true
+}
+
+// Put : Add a page to the cache.
+func (d *DiskPageCache) Put(key PageKey, data []byte) error {
+	path := d.path(key)
+	old := fileSize(path)
+	if err := writeFileAtomic(path, data, 0o644); err != nil {
+		return err
+	}
+	d.added(int64(len(data)) - old)
+	return nil
+}
+
+// NewPageWriter : Start adding a page, which is written to a temporary file as it arrives.
+func (d *DiskPageCache) NewPageWriter(key PageKey) (PageWriter, error) {
+	path := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &diskPageWriter{cache: d, path: path, file: f}, nil
+}
+
+// Size : Get the total size of the cached pages in bytes.
+func (d *DiskPageCache) Size() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.size
+}
+
+// added : Account for n bytes added to the cache, starting an eviction if it is over its limit.
+func (d *DiskPageCache) added(n int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.size += n
+	if d.size <= d.maxBytes || d.evicting {
+		return
+	}
+
+	d.evicting = true
+	d.evictions.Add(1)
+	go func() {
+		defer d.evictions.Done()
+		d.evict()
+	}()
+}
+
+// evict : Remove the least recently used pages until the cache is reduced to evictTarget of its limit.
+// The directory is scanned again, as other processes may have added or removed pages.
+func (d *DiskPageCache) evict() {
+	d.mu.Lock()
+	start := d.size
+	d.mu.Unlock()
+
+	files, err := d.files()
+	var total int64
+	if err == nil {
+		slices.SortFunc(files, func(a, b cachedPage) int {
+			return a.used.Compare(b.used)
+		})
+		for _, f := range files {
+			total += f.size
+		}
+
+		target := int64(float64(d.maxBytes) * evictTarget)
+		for _, f := range files {
+			if total <= target {
+				break
+			}
+			if err := os.Remove(f.path); err == nil || errors.Is(err, fs.ErrNotExist) {
+				total -= f.size
+			}
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err == nil {
+		// Keep pages added while scanning.
+		d.size = total + d.size - start
+	}
+	d.evicting = false
+}
+
+// cachedPage : A page file in a DiskPageCache.
+type cachedPage struct {
+	path string
+	size int64
+	used time.Time
+}
+
+// files : List the pages in the cache. Files that are still being written are skipped, and removed if they
+// were left behind by an interrupted write.
+func (d *DiskPageCache) files() ([]cachedPage, error) {
+	var files []cachedPage
+	stale := time.Now().Add(-staleTempAge)
+	err := filepath.WalkDir(d.dir, func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			// Pages may be removed by other processes while walking.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if e.IsDir() {
+			return nil
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return nil
+		}
+		if strings.HasSuffix(path, ".tmp") {
+			if fi.ModTime().Before(stale) {
+				os.Remove(path)
+			}
+			return nil
+		}
+		files = append(files, cachedPage{path: path, size: fi.Size(), used: fi.ModTime()})
+		return nil
+	})
+	return files, err
+}
+
+// path : Get the file of a page, named after the hash of its key.
+func (d *DiskPageCache) path(key PageKey) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{key.Hash, key.Quality, key.Filename}, "/")))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(d.dir, name[:2], name)
+}
+
+// diskPageWriter : A page being written to a temporary file of a DiskPageCache.
+type diskPageWriter struct {
+	cache *DiskPageCache
+	path  string
+	file  *os.File
+	n     int64
+}
+
+func (w *diskPageWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// Commit : Move the temporary file into place, replacing any previous version of the page.
+func (w *diskPageWriter) Commit() error {
+	old := fileSize(w.path)
+	err := w.file.Chmod(0o644)
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(w.file.Name(), w.path)
+	}
+	if err != nil {
+		os.Remove(w.file.Name())
+		return err
+	}
+	w.cache.added(w.n - old)
+	return nil
+}
+
+func (w *diskPageWriter) Abort() error {
+	w.file.Close()
+	return os.Remove(w.file.Name())
+}
+
+// fileSize : Get the size of the file at path, or 0 if it does not exist.
+func fileSize(path string) int64 {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
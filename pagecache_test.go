@@ -0,0 +1,134 @@
+package mangodex
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMDHomePageCache(t *testing.T) {
+	f, _ := newFakeAtHome(t, 2)
+	cache, err := NewDiskPageCache(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatalf("new cache: %s", err)
+	}
+	c := NewDexClient(WithBaseURL(f.srv.URL), WithReportURL(f.srv.URL+"/report"), WithPageCache(cache))
+	mc, err := c.AtHome.NewMDHomeClient("chapter", "data", false)
+	if err != nil {
+		t.Fatalf("new client: %s", err)
+	}
+
+	if _, err = mc.GetChapterPage(mc.Pages[0]); err != nil {
+		t.Fatalf("get page: %s", err)
+	}
+	ps, err := mc.GetChapterPageStream(context.Background(), mc.Pages[1])
+	if err != nil {
+		t.Fatalf("open stream: %s", err)
+	}
+	io.Copy(io.Discard, ps)
+	ps.Close()
+	if cache.Size() != 12 {
+		t.Errorf("expected 12 cached bytes, got %d", cache.Size())
+	}
+
+	// Pages that were not read completely are not cached, and leave no temporary files.
+	f.pages["p2-abc.png"] = []byte("page 2")
+	ps, err = mc.GetChapterPageStream(context.Background(), "p2-abc.png")
+	if err != nil {
+		t.Fatalf("open stream: %s", err)
+	}
+	ps.Read(make([]byte, 2))
+	ps.Close()
+	if _, ok := cache.Get(PageKey{Hash: "hash", Quality: "data", Filename: "p2-abc.png"}); ok {
+		t.Error("partially read page was cached")
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(cache.dir, "*", "*.tmp")); len(tmp) != 0 {
+		t.Errorf("temporary files left behind: %v", tmp)
+	}
+
+	// Cached pages are not fetched again, and are shared by clients of other chapters and processes.
+	f.broken["data"] = true
+	shared, err := NewDiskPageCache(cache.dir, 1<<20)
+	if err != nil {
+		t.Fatalf("reopen cache: %s", err)
+	}
+	mc.cache = shared
+	data, err := mc.GetChapterPage(mc.Pages[0])
+	if err != nil || string(data) != "page 0" {
+		t.Errorf("unexpected cached page %q, %v", data, err)
+	}
+	ps, err = mc.GetChapterPageStream(context.Background(), mc.Pages[1])
+	if err != nil {
+		t.Fatalf("open cached stream: %s", err)
+	}
+	data, _ = io.ReadAll(ps)
+	ps.Close()
+	if string(data) != "page 1" || ps.Source.BaseURL != "" || ps.ContentLength != 6 {
+		t.Errorf("unexpected cached stream %q from %+v", data, ps.Source)
+	}
+
+	// Data-saver pages used as a fallback are not cached as the original quality.
+	mc.FallbackToDataSaver, mc.MaxPageAttempts = true, 2
+	mc.data = append(mc.data, "p2-abc.png")
+	mc.dataSaver = append(mc.dataSaver, "s2-abc.jpg")
+	if _, err = mc.GetChapterPage("p2-abc.png"); err != nil {
+		t.Fatalf("get page with fallback: %s", err)
+	}
+	if _, ok := shared.Get(PageKey{Hash: "hash", Quality: "data", Filename: "p2-abc.png"}); ok {
+		t.Error("data-saver page cached as original quality")
+	}
+	if _, ok := shared.Get(PageKey{Hash: "hash", Quality: dataSaver, Filename: "s2-abc.jpg"}); !ok {
+		t.Error("data-saver page was not cached")
+	}
+}
+
+func TestDiskPageCacheEviction(t *testing.T) {
+	cache, err := NewDiskPageCache(t.TempDir(), 20)
+	if err != nil {
+		t.Fatalf("new cache: %s", err)
+	}
+
+	var keys []PageKey
+	for i, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		keys = append(keys, PageKey{"h", "data", name})
+		if i >= 4 {
+			continue
+		}
+		if err = cache.Put(keys[i], []byte("12345")); err != nil {
+			t.Fatalf("put: %s", err)
+		}
+		used := time.Now().Add(time.Duration(i-10) * time.Minute)
+		os.Chtimes(cache.path(keys[i]), used, used)
+	}
+
+	// Reading a page makes it the most recently used one.
+	if _, ok := cache.Get(keys[0]); !ok {
+		t.Fatal("page not cached")
+	}
+
+	// Going over the limit evicts down to 90% of it, leaving room for the next page without another scan.
+	if err = cache.Put(keys[4], []byte("12345")); err != nil {
+		t.Fatalf("put: %s", err)
+	}
+	cache.evictions.Wait()
+	if cache.Size() != 15 {
+		t.Errorf("expected 15 cached bytes after eviction, got %d", cache.Size())
+	}
+	if err = cache.Put(keys[5], []byte("12345")); err != nil {
+		t.Fatalf("put: %s", err)
+	}
+	cache.evictions.Wait()
+
+	for i, key := range keys {
+		_, ok := cache.Get(key)
+		if evicted := i == 1 || i == 2; ok == evicted {
+			t.Errorf("page %s: cached %v, want %v", key.Filename, ok, !evicted)
+		}
+	}
+	if cache.Size() != 20 {
+		t.Errorf("expected 20 cached bytes, got %d", cache.Size())
+	}
+}
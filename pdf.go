@@ -0,0 +1,373 @@
+package mangodex
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// PDFExporter : Writes chapters and volumes of a manga as PDF documents, with one page per image.
+type PDFExporter struct {
+	client *DexClient
+
+	// Language : Language of the chapters included, also used for the title of the document.
+	Language string
+	// Quality : Quality of the pages, either "data" or "data-saver".
+	Quality string
+}
+
+// NewPDFExporter : Create a PDFExporter for chapters translated to lang.
+func NewPDFExporter(client *DexClient, lang string) *PDFExporter {
+	return &PDFExporter{
+		client:   client,
+		Language: lang,
+		Quality:  "data",
+	}
+}
+
+// WriteChapter : Write a chapter of a manga as a PDF.
+func (e *PDFExporter) WriteChapter(ctx context.Context, w io.Writer, manga *Manga, chapter *Chapter) error {
+	ec, err := fetchChapterPages(ctx, e.client, 0, chapter, e.Quality)
+	if err != nil {
+		return err
+	}
+	doc := e.document(manga, fmt.Sprintf("%s %s", manga.GetTitle(e.Language), ec.Title))
+	doc.Chapters = []exportChapter{*ec}
+	return doc.write(w)
+}
+
+// WriteChapterFile : WriteChapter to a file at path. The file only appears once it has been written completely.
+func (e *PDFExporter) WriteChapterFile(ctx context.Context, path string, manga *Manga, chapter *Chapter) error {
+	return writeFileFunc(path, func(w io.Writer) error {
+		return e.WriteChapter(ctx, w, manga, chapter)
+	})
+}
+
+// WriteVolume : Write a volume of a manga as a PDF, with a bookmark for each chapter.
+func (e *PDFExporter) WriteVolume(ctx context.Context, w io.Writer, manga *Manga, volume string) error {
+	chapters, err := e.client.Chapter.GetVolumeChaptersContext(ctx, manga.ID, volume, e.Language)
+	if err != nil {
+		return err
+	}
+
+	doc := e.document(manga, fmt.Sprintf("%s Vol. %s", manga.GetTitle(e.Language), volume))
+	for i := range chapters {
+		ec, err := fetchChapterPages(ctx, e.client, i, &chapters[i], e.Quality)
+		if err != nil {
+			return err
+		}
+		doc.Chapters = append(doc.Chapters, *ec)
+	}
+	return doc.write(w)
+}
+
+// WriteVolumeFile : WriteVolume to a file at path. The file only appears once it has been written completely.
+func (e *PDFExporter) WriteVolumeFile(ctx context.Context, path string, manga *Manga, volume string) error {
+	return writeFileFunc(path, func(w io.Writer) error {
+		return e.WriteVolume(ctx, w, manga, volume)
+	})
+}
+
+// document : Create a document with the metadata of a manga.
+func (e *PDFExporter) document(manga *Manga, title string) *pdfDocument {
+	return &pdfDocument{
+		Title:    title,
+		Author:   strings.Join(append(relationshipNames(manga.Relationships, AuthorRel), relationshipNames(manga.Relationships, ArtistRel)...), ", "),
+		Subject:  manga.GetDescription(e.Language),
+		Created:  time.Now().UTC(),
+		Language: e.Language,
+	}
+}
+
+// pdfDocument : Contents and metadata of a PDF.
+type pdfDocument struct {
+	Title    string
+	Author   string
+	Subject  string
+	Language string
+	Created  time.Time
+	Chapters []exportChapter
+}
+
+// Numbers of the objects written before the pages.
+const (
+	pdfCatalog = iota + 1
+	pdfPages
+	pdfOutlines
+	pdfInfo
+	pdfFirstPage
+)
+
+// Objects written for every page: the page, its content stream and its image. Images with transparency
+// have an extra soft mask object.
+const pdfPageObjects = 4
+
+// write : Write the document as a PDF.
+func (d *pdfDocument) write(w io.Writer) error {
+	pw := &pdfWriter{w: bufio.NewWriter(w)}
+	pw.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// Every page has a fixed block of object numbers, followed by one outline item per chapter.
+	// Chapters without pages have nothing to point to, so they are left out of the outline.
+	type outlineItem struct {
+		title string
+		dest  int
+	}
+	var (
+		kids    []string
+		outline []outlineItem
+		total   int
+	)
+	for _, ch := range d.Chapters {
+		if len(ch.Pages) != 0 {
+			outline = append(outline, outlineItem{ch.Title, pdfFirstPage + total*pdfPageObjects})
+		}
+		for range ch.Pages {
+			kids = append(kids, fmt.Sprintf("%d 0 R", pdfFirstPage+total*pdfPageObjects))
+			total++
+		}
+	}
+	firstItem := pdfFirstPage + total*pdfPageObjects
+
+	catalog := fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R /Outlines %d 0 R /PageMode /UseOutlines", pdfPages, pdfOutlines)
+	if d.Language != "" {
+		catalog += " /Lang " + pdfString(d.Language)
+	}
+	pw.object(pdfCatalog, catalog+" >>")
+	pw.object(pdfPages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), total))
+	if len(outline) == 0 {
+		pw.object(pdfOutlines, "<< /Type /Outlines /Count 0 >>")
+	} else {
+		pw.object(pdfOutlines, fmt.Sprintf("<< /Type /Outlines /First %d 0 R /Last %d 0 R /Count %d >>",
+			firstItem, firstItem+len(outline)-1, len(outline)))
+	}
+	pw.object(pdfInfo, fmt.Sprintf("<< /Title %s /Author %s /Subject %s /Producer %s /CreationDate %s >>",
+		pdfString(d.Title), pdfString(d.Author), pdfString(d.Subject), pdfString("mangodex"),
+		pdfString(d.Created.Format("D:20060102150405Z"))))
+
+	id := pdfFirstPage
+	for _, ch := range d.Chapters {
+		for _, p := range ch.Pages {
+			if err := pw.page(id, p); err != nil {
+				return fmt.Errorf("page %s: %w", p.Name, err)
+			}
+			id += pdfPageObjects
+		}
+	}
+
+	for i, o := range outline {
+		item := fmt.Sprintf("<< /Title %s /Parent %d 0 R /Dest [%d 0 R /Fit]", pdfString(o.title), pdfOutlines, o.dest)
+		if i > 0 {
+			item += fmt.Sprintf(" /Prev %d 0 R", firstItem+i-1)
+		}
+		if i < len(outline)-1 {
+			item += fmt.Sprintf(" /Next %d 0 R", firstItem+i+1)
+		}
+		pw.object(firstItem+i, item+" >>")
+	}
+
+	return pw.finish(firstItem+len(outline), pdfCatalog, pdfInfo)
+}
+
+// pdfWriter : Writes PDF objects, keeping track of their offsets for the cross-reference table.
+type pdfWriter struct {
+	w       *bufio.Writer
+	n       int64
+	offsets map[int]int64
+	err     error
+}
+
+// printf : Write formatted output, remembering the first error.
+func (pw *pdfWriter) printf(format string, args ...any) {
+	if pw.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(pw.w, format, args...)
+	pw.n += int64(n)
+	pw.err = err
+}
+
+// write : Write raw data, remembering the first error.
+func (pw *pdfWriter) write(data []byte) {
+	if pw.err != nil {
+		return
+	}
+	n, err := pw.w.Write(data)
+	pw.n += int64(n)
+	pw.err = err
+}
+
+// begin : Start the object with number id.
+func (pw *pdfWriter) begin(id int) {
+	if pw.offsets == nil {
+		pw.offsets = map[int]int64{}
+	}
+	pw.offsets[id] = pw.n
+	pw.printf("%d 0 obj\n", id)
+}
+
+// object : Write an object with number id.
+func (pw *pdfWriter) object(id int, body string) {
+	pw.begin(id)
+	pw.printf("%s\nendobj\n", body)
+}
+
+// stream : Write a stream object with number id. dict holds the entries of the stream dictionary besides its length.
+func (pw *pdfWriter) stream(id int, dict string, data []byte) {
+	pw.begin(id)
+	pw.printf("<< %s /Length %d >>\nstream\n", dict, len(data))
+	pw.write(data)
+	pw.printf("\nendstream\nendobj\n")
+}
+
+// page : Write a page with number id showing a full-page image. The page uses the following object numbers
+// for its content stream, image and soft mask.
+func (pw *pdfWriter) page(id int, p exportPage) error {
+	img, err := pdfEncodeImage(p.Data)
+	if err != nil {
+		return err
+	}
+
+	pw.object(id, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /XObject << /Im0 %d 0 R >> >> /Contents %d 0 R >>",
+		pdfPages, img.width, img.height, id+2, id+1))
+	pw.stream(id+1, "", []byte(fmt.Sprintf("q %d 0 0 %d 0 0 cm /Im0 Do Q", img.width, img.height)))
+
+	dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter %s",
+		img.width, img.height, img.colorSpace, img.filter)
+	if img.decode != "" {
+		dict += " /Decode " + img.decode
+	}
+	if img.mask != nil {
+		dict += fmt.Sprintf(" /SMask %d 0 R", id+3)
+	}
+	pw.stream(id+2, dict, img.data)
+
+	// Keep the object numbers of every page in the same block, even without a mask.
+	if img.mask != nil {
+		pw.stream(id+3, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode",
+			img.width, img.height), img.mask)
+	} else {
+		pw.object(id+3, "null")
+	}
+	return pw.err
+}
+
+// finish : Write the cross-reference table and trailer for objects numbered below size.
+func (pw *pdfWriter) finish(size, root, info int) error {
+	xref := pw.n
+	pw.printf("xref\n0 %d\n0000000000 65535 f \n", size)
+	for id := 1; id < size; id++ {
+		offset, ok := pw.offsets[id]
+		if !ok {
+			pw.printf("0000000000 65535 f \n")
+			continue
+		}
+		pw.printf("%010d 00000 n \n", offset)
+	}
+	pw.printf("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, root, info, xref)
+	if pw.err != nil {
+		return pw.err
+	}
+	return pw.w.Flush()
+}
+
+// pdfImage : An image encoded for embedding in a PDF.
+type pdfImage struct {
+	width, height int
+	colorSpace    string
+	filter        string
+	decode        string
+	data          []byte
+	mask          []byte
+}
+
+// pdfEncodeImage : Encode a page for embedding. JPEGs are embedded as they are, other formats are decoded
+// and compressed, with their transparency as a soft mask.
+func pdfEncodeImage(data []byte) (*pdfImage, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported image: %w", err)
+	}
+
+	if format == "jpeg" {
+		img := &pdfImage{width: cfg.Width, height: cfg.Height, filter: "/DCTDecode", data: data}
+		switch cfg.ColorModel {
+		case color.GrayModel:
+			img.colorSpace = "/DeviceGray"
+		case color.CMYKModel:
+			// CMYK JPEGs are written inverted by Adobe applications, which produce nearly all of them.
+			img.colorSpace, img.decode = "/DeviceCMYK", "[1 0 1 0 1 0 1 0]"
+		default:
+			img.colorSpace = "/DeviceRGB"
+		}
+		return img, nil
+	}
+
+	m, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	b := m.Bounds()
+	opaque := true
+	if o, ok := m.(interface{ Opaque() bool }); ok {
+		opaque = o.Opaque()
+	}
+
+	rgb := make([]byte, 0, b.Dx()*b.Dy()*3)
+	var alpha []byte
+	if !opaque {
+		alpha = make([]byte, 0, b.Dx()*b.Dy())
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA)
+			rgb = append(rgb, c.R, c.G, c.B)
+			if !opaque {
+				alpha = append(alpha, c.A)
+			}
+		}
+	}
+
+	img := &pdfImage{width: b.Dx(), height: b.Dy(), colorSpace: "/DeviceRGB", filter: "/FlateDecode"}
+	if img.data, err = deflate(rgb); err != nil {
+		return nil, err
+	}
+	if alpha != nil {
+		if img.mask, err = deflate(alpha); err != nil {
+			return nil, err
+		}
+	}
+	return img, nil
+}
+
+// deflate : Compress data for a FlateDecode stream.
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pdfString : Encode a text string as UTF-16 with a byte order mark, so that any title can be shown.
+func pdfString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
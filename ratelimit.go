@@ -0,0 +1,192 @@
+package mangodex
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimit : Global request limit enforced by MangaDex, in requests per second.
+const DefaultRateLimit = 5
+
+// DefaultRouteRateLimits : Per-endpoint limits documented by MangaDex, keyed by path template.
+var DefaultRouteRateLimits = map[string]RateLimit{
+	GetMDHomeURLPath: {Rate: 40.0 / 60, Burst: 40},
+	LoginPath:        {Rate: 30.0 / 3600, Burst: 30},
+	RefreshTokenPath: {Rate: 60.0 / 3600, Burst: 60},
+}
+
+// RateLimit : Token bucket allowing Rate requests per second, with bursts of up to Burst requests.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// bucket : Token bucket that can additionally be paused until a point in time.
+// A bucket with a zero rate only enforces pauses.
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	until  time.Time
+}
+
+func newBucket(limit RateLimit) *bucket {
+	burst := float64(max(limit.Burst, 1))
+	return &bucket{
+		rate:   limit.Rate,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// reserve : Take a token from the bucket. Returns how long to wait before sending,
+// and whether a token was taken. When paused, no token is taken and the caller must try again.
+func (b *bucket) reserve(now time.Time) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.until.After(now) {
+		return b.until.Sub(now), false
+	}
+	if b.rate <= 0 {
+		return 0, true
+	}
+
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0, true
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second)), true
+}
+
+// wait : Block until a request may be sent or the context is done.
+func (b *bucket) wait(ctx context.Context) error {
+	for {
+		d, reserved := b.reserve(time.Now())
+		if d <= 0 {
+			return nil
+		}
+		if err := sleepContext(ctx, d); err != nil {
+			return err
+		}
+		if reserved {
+			return nil
+		}
+	}
+}
+
+// pause : Stop handing out tokens until t.
+func (b *bucket) pause(t time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t.After(b.until) {
+		b.until = t
+	}
+}
+
+// rateLimiter : Global and per-route token buckets for a DexClient.
+type rateLimiter struct {
+	global *bucket
+
+	mu     sync.Mutex
+	routes map[string]*bucket
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		global: newBucket(RateLimit{}),
+		routes: map[string]*bucket{},
+	}
+}
+
+// routeBucket : Get the bucket for a route, creating a pause-only bucket if none is configured.
+func (l *rateLimiter) routeBucket(route string) *bucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.routes[route]
+	if !ok {
+		b = newBucket(RateLimit{})
+		l.routes[route] = b
+	}
+	return b
+}
+
+// wait : Block until both the global and route limits allow a request.
+func (l *rateLimiter) wait(ctx context.Context, route string) error {
+	if err := l.global.wait(ctx); err != nil {
+		return err
+	}
+	if route == "" {
+		return nil
+	}
+	return l.routeBucket(route).wait(ctx)
+}
+
+// update : Adapt to the rate limit headers of a response.
+func (l *rateLimiter) update(route string, resp *http.Response) {
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if remaining != "0" && resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	d := retryAfter(resp.Header, time.Now())
+	if d <= 0 {
+		return
+	}
+
+	b := l.global
+	if route != "" {
+		b = l.routeBucket(route)
+	}
+	b.pause(time.Now().Add(d))
+}
+
+// sleepContext : Sleep for d, returning early with the context's error if it is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// WithRateLimit : Limit the client to rate requests per second across all endpoints, with bursts of up to burst.
+func WithRateLimit(rate float64, burst int) Option {
+	return func(c *DexClient) {
+		c.limiter().global = newBucket(RateLimit{Rate: rate, Burst: burst})
+	}
+}
+
+// WithRouteRateLimit : Limit requests to a single endpoint, identified by its path template such as GetMDHomeURLPath.
+func WithRouteRateLimit(route string, rate float64, burst int) Option {
+	return func(c *DexClient) {
+		c.limiter().routes[route] = newBucket(RateLimit{Rate: rate, Burst: burst})
+	}
+}
+
+// WithDefaultRateLimits : Apply DefaultRateLimit and DefaultRouteRateLimits.
+func WithDefaultRateLimits() Option {
+	return func(c *DexClient) {
+		WithRateLimit(DefaultRateLimit, DefaultRateLimit)(c)
+		for route, limit := range DefaultRouteRateLimits {
+			WithRouteRateLimit(route, limit.Rate, limit.Burst)(c)
+		}
+	}
+}
+
+// limiter : Get the rate limiter of the client, creating it if required.
+func (c *DexClient) limiter() *rateLimiter {
+	if c.rateLimiter == nil {
+		c.rateLimiter = newRateLimiter()
+	}
+	return c.rateLimiter
+}
@@ -0,0 +1,227 @@
+// Package readserver provides a net/http handler serving manga, chapters and pages from MangaDex,
+// for use by self-hosted readers.
+//
+// Pages are streamed from MangaDex@Home, and the results are reported to MangaDex@Home by the handler.
+// Call Close on the DexClient when shutting down, so that queued reports are sent.
+package readserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	md "github.com/KidEkko/mangodex"
+)
+
+// DefaultServerTTL : How long a MangaDex@Home server is used for a chapter before requesting a new one.
+// Server URLs handed out by the API stay valid for about 15 minutes.
+const DefaultServerTTL = 10 * time.Minute
+
+// Handler : Serves the following routes:
+//
+//	GET /manga/{id}               Manga, with author, artist and cover art relationships.
+//	GET /chapter/{id}             Chapter, with the URLs of its pages.
+//	GET /chapter/{id}/page/{n}    Image of page n of a chapter, starting from 1.
+type Handler struct {
+	client *md.DexClient
+	mux    *http.ServeMux
+
+	// Quality : Quality of the pages served, either "data" or "data-saver".
+	Quality string
+	// ServerTTL : How long a MangaDex@Home server is used for a chapter.
+	ServerTTL time.Duration
+
+	mu      sync.Mutex
+	servers map[string]*server
+}
+
+// server : MangaDex@Home server for a chapter, shared by all requests for the chapter until it expires.
+type server struct {
+	ready   chan struct{}
+	client  *md.MDHomeClient
+	err     error
+	expires time.Time
+}
+
+// ChapterResponse : Response for the chapter route.
+type ChapterResponse struct {
+	Chapter md.Chapter `json:"chapter"`
+	Pages   []string   `json:"pages"`
+}
+
+// New : Create a Handler fetching from MangaDex with client.
+func New(client *md.DexClient) *Handler {
+	h := &Handler{
+		client:    client,
+		mux:       http.NewServeMux(),
+		Quality:   "data",
+		ServerTTL: DefaultServerTTL,
+		servers:   map[string]*server{},
+	}
+	h.mux.HandleFunc("GET /manga/{id}", h.serveManga)
+	h.mux.HandleFunc("GET /chapter/{id}", h.serveChapter)
+	h.mux.HandleFunc("GET /chapter/{id}/page/{n}", h.servePage)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) serveManga(w http.ResponseWriter, r *http.Request) {
+	m, err := h.client.Manga.GetMangaWithContext(r.Context(), r.PathValue("id"), &md.GetMangaParams{
+		Includes: []string{md.AuthorRel, md.ArtistRel, md.CoverArtRel},
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, &m.Manga)
+}
+
+func (h *Handler) serveChapter(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	c, err := h.client.Chapter.GetMangaChapterWithContext(r.Context(), id, &md.GetChapterParams{
+		Includes: []string{md.ScanlationGroupRel},
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s, err := h.server(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resp := &ChapterResponse{Chapter: c.Chapter}
+	for i := range s.client.Pages {
+		resp.Pages = append(resp.Pages, fmt.Sprintf("/chapter/%s/page/%d", id, i+1))
+	}
+	writeJSON(w, resp)
+}
+
+func (h *Handler) servePage(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	n, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil || n < 1 {
+		http.Error(w, "invalid page number", http.StatusBadRequest)
+		return
+	}
+
+	s, err := h.server(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if n > len(s.client.Pages) {
+		http.Error(w, "page not found", http.StatusNotFound)
+		return
+	}
+
+	ps, err := s.client.GetChapterPageStream(r.Context(), s.client.Pages[n-1])
+	if err != nil {
+		if errors.Is(err, md.ErrChapterChanged) {
+			h.forget(id, s)
+		}
+		writeError(w, err)
+		return
+	}
+	defer ps.Close()
+
+	if ps.ContentType != "" {
+		w.Header().Set("Content-Type", ps.ContentType)
+	}
+	if ps.ContentLength >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(ps.ContentLength, 10))
+	}
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	io.Copy(w, ps)
+}
+
+// server : Get the MangaDex@Home server for a chapter, requesting one only if there is none or it has expired.
+func (h *Handler) server(ctx context.Context, chapterID string) (*server, error) {
+	h.mu.Lock()
+	s, ok := h.servers[chapterID]
+	if ok && s.expired(time.Now()) {
+		ok = false
+	}
+	if !ok {
+		h.evictExpired(time.Now())
+		s = &server{ready: make(chan struct{})}
+		h.servers[chapterID] = s
+		go h.fetchServer(ctx, chapterID, s)
+	}
+	h.mu.Unlock()
+
+	select {
+	case <-s.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if s.err != nil {
+		h.forget(chapterID, s)
+		return nil, s.err
+	}
+	return s, nil
+}
+
+// fetchServer : Request a server for a chapter. The request is shared, so it is not cancelled with ctx.
+func (h *Handler) fetchServer(ctx context.Context, chapterID string, s *server) {
+	defer close(s.ready)
+	s.client, s.err = h.client.AtHome.NewMDHomeClientContext(context.WithoutCancel(ctx), chapterID, h.Quality, false)
+	s.expires = time.Now().Add(h.ServerTTL)
+}
+
+// expired : Check whether the server must no longer be used. Servers still being requested never expire.
+func (s *server) expired(now time.Time) bool {
+	select {
+	case <-s.ready:
+		return now.After(s.expires)
+	default:
+		return false
+	}
+}
+
+// evictExpired : Remove expired servers. Must be called with mu held.
+func (h *Handler) evictExpired(now time.Time) {
+	for id, s := range h.servers {
+		if s.expired(now) {
+			delete(h.servers, id)
+		}
+	}
+}
+
+// forget : Remove the server of a chapter, if it has not been replaced already.
+func (h *Handler) forget(chapterID string, s *server) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.servers[chapterID] == s {
+		delete(h.servers, chapterID)
+	}
+}
+
+// writeJSON : Write v as a JSON response.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError : Write an error response, passing through not found errors from the API.
+// Only the status is sent, as errors can contain MangaDex@Home URLs with their access token.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	switch {
+	case md.IsNotFound(err):
+		status = http.StatusNotFound
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusGatewayTimeout
+	}
+	http.Error(w, http.StatusText(status), status)
+}
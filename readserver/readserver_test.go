@@ -0,0 +1,108 @@
+package readserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	md "github.com/KidEkko/mangodex"
+)
+
+func TestHandler(t *testing.T) {
+	var servers, reports atomic.Int64
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/manga/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "m1" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"result":"error","errors":[{"status":404,"title":"not_found"}]}`))
+			return
+		}
+		w.Write([]byte(`{"result":"ok","data":{"id":"m1","type":"manga","attributes":{"title":{"en":"Test"}}}}`))
+	})
+	mux.HandleFunc("/chapter/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":"ok","data":{"id":"c1","type":"chapter","attributes":{"chapter":"1"}}}`))
+	})
+	mux.HandleFunc("/at-home/server/{id}", func(w http.ResponseWriter, r *http.Request) {
+		servers.Add(1)
+		w.Write([]byte(`{"result":"ok","baseUrl":"` + srv.URL + `/node","chapter":{"hash":"h","data":["a.png","b.png"]}}`))
+	})
+	mux.HandleFunc("/node/data/h/{file}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("image " + r.PathValue("file")))
+	})
+	mux.HandleFunc("/report", func(w http.ResponseWriter, r *http.Request) {
+		reports.Add(1)
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := md.NewDexClient(md.WithBaseURL(srv.URL), md.WithReportURL(srv.URL+"/report"))
+	h := New(client)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	if rec := get("/manga/m1"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"id":"m1"`) {
+		t.Errorf("unexpected manga response %d %s", rec.Code, rec.Body)
+	}
+	if rec := get("/manga/missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for missing manga, got %d", rec.Code)
+	}
+
+	rec := get("/chapter/c1")
+	var cr ChapterResponse
+	if err := json.NewDecoder(rec.Body).Decode(&cr); err != nil || len(cr.Pages) != 2 || cr.Pages[1] != "/chapter/c1/page/2" {
+		t.Fatalf("unexpected chapter response %+v, %v", cr, err)
+	}
+
+	rec = get("/chapter/c1/page/2")
+	body, _ := io.ReadAll(rec.Body)
+	if rec.Code != http.StatusOK || string(body) != "image b.png" || rec.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("unexpected page response %d %q", rec.Code, body)
+	}
+	if rec = get("/chapter/c1/page/3"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for missing page, got %d", rec.Code)
+	}
+
+	// The server of the chapter is requested once and reused.
+	if n := servers.Load(); n != 1 {
+		t.Errorf("expected 1 server request, got %d", n)
+	}
+	client.Close()
+	if n := reports.Load(); n != 1 {
+		t.Errorf("expected 1 report, got %d", n)
+	}
+}
+
+func TestHandlerHidesNodeURL(t *testing.T) {
+	// The server of the chapter is unreachable, and its URL contains a token.
+	dead := httptest.NewServer(http.NotFoundHandler())
+	nodeURL := dead.URL + "/SECRETTOKEN"
+	dead.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/at-home/server/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":"ok","baseUrl":"` + nodeURL + `","chapter":{"hash":"h","data":["a.png"]}}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := md.NewDexClient(md.WithBaseURL(srv.URL), md.WithAtHomeReporting(false))
+	rec := httptest.NewRecorder()
+	New(client).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/chapter/c1/page/1", nil))
+
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("expected 502 for unreachable server, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); strings.Contains(body, "SECRETTOKEN") || strings.Contains(body, dead.URL) {
+		t.Errorf("response exposes the server URL: %s", body)
+	}
+}
@@ -0,0 +1,122 @@
+package mangodex
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// refreshSkew : How long before its expiry a session token is refreshed.
+const refreshSkew = 30 * time.Second
+
+// WithAutoRefresh : Enable or disable automatic session refreshing, which is enabled by default.
+// When enabled, the session token is refreshed when it is about to expire or a request fails with 401,
+// after which the request is sent again.
+func WithAutoRefresh(enabled bool) Option {
+	return func(c *DexClient) {
+		c.autoRefresh = enabled
+	}
+}
+
+// refreshCall : An in-flight session refresh, shared by all callers waiting for it.
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+// refresher : Makes sure only one session refresh runs at a time.
+type refresher struct {
+	mu   sync.Mutex
+	call *refreshCall
+}
+
+// tokenExpiry : Decode the expiry time of a JWT, without verifying it.
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err = json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
+
+// canRefresh : Check whether a request to route may trigger a session refresh.
+func (c *DexClient) canRefresh(route string) bool {
+	if !c.autoRefresh || route == LoginPath || route == RefreshTokenPath || route == LogoutPath {
+		return false
+	}
+	_, refresh := c.creds.get()
+	return refresh != ""
+}
+
+// sessionFor : Get the session token to send with a request, refreshing it first if it is about to expire.
+func (c *DexClient) sessionFor(ctx context.Context, ar *apiRequest) (string, error) {
+	if ar.anonymous {
+		return "", nil
+	}
+	session, _ := c.creds.get()
+	if session == "" || !c.canRefresh(ar.route) {
+		return session, nil
+	}
+	if exp, ok := c.creds.expiresAt(); ok && time.Now().Add(refreshSkew).After(exp) {
+		if err := c.refreshSession(ctx, session); err != nil {
+			return "", err
+		}
+		session, _ = c.creds.get()
+	}
+	return session, nil
+}
+
+// refreshSession : Refresh the session token, unless it has already been replaced since stale was read.
+// Concurrent callers share a single refresh request.
+func (c *DexClient) refreshSession(ctx context.Context, stale string) error {
+	c.refresher.mu.Lock()
+	if session, _ := c.creds.get(); session != stale {
+		c.refresher.mu.Unlock()
+		return nil
+	}
+	call := c.refresher.call
+	if call == nil {
+		call = &refreshCall{done: make(chan struct{})}
+		c.refresher.call = call
+		go func() {
+			// The refresh is shared, so it must not be cancelled along with the context of one caller.
+			call.err = c.refreshCredentials(context.WithoutCancel(ctx))
+
+			c.refresher.mu.Lock()
+			c.refresher.call = nil
+			c.refresher.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	c.refresher.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// refreshCredentials : Refresh the session with the same flow that was used to login.
+func (c *DexClient) refreshCredentials(ctx context.Context) error {
+	if c.creds.isOAuth() {
+		_, err := c.Auth.RefreshOAuthTokenContext(ctx)
+		return err
+	}
+	return c.Auth.RefreshSessionTokenContext(ctx)
+}
@@ -0,0 +1,153 @@
+package mangodex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// reportQueueSize : Number of reports that can be waiting to be sent. Further reports are dropped.
+	reportQueueSize = 256
+	// reportTimeout : Time allowed for sending a single report.
+	reportTimeout = 10 * time.Second
+)
+
+// WithAtHomeReporting : Enable or disable reporting page fetch results to MangaDex@Home, which is enabled by default.
+// Reports are never sent for pages served from mangadex.org hosts, as required by the API rules.
+func WithAtHomeReporting(enabled bool) Option {
+	return func(c *DexClient) {
+		c.reportDisabled = !enabled
+	}
+}
+
+// reportPayload : Required fields for reporting page download result.
+type reportPayload struct {
+	URL      string `json:"url"`
+	Success  bool   `json:"success"`
+	Bytes    int    `json:"bytes"`
+	Duration int64  `json:"duration"`
+	Cached   bool   `json:"cached"`
+}
+
+func newPayload(path string) *reportPayload {
+	return &reportPayload{
+		URL: path,
+	}
+}
+
+// reporter : Sends MangaDex@Home reports from a bounded queue in the background.
+// Reports outlive the context of the page fetch they describe, until the reporter is closed.
+type reporter struct {
+	client *http.Client
+	header http.Header
+	url    string
+
+	once   sync.Once
+	mu     sync.Mutex
+	closed bool
+	queue  chan reportPayload
+	done   chan struct{}
+}
+
+func newReporter(client *http.Client, header http.Header, reportURL string) *reporter {
+	header.Set("Content-Type", "application/json")
+	return &reporter{
+		client: client,
+		header: header,
+		url:    reportURL,
+		queue:  make(chan reportPayload, reportQueueSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// reportable : Check whether fetches from a page URL should be reported.
+func reportable(pageURL string) bool {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	return host != "mangadex.org" && !strings.HasSuffix(host, ".mangadex.org")
+}
+
+// submit : Queue a report, dropping it if the queue is full or the reporter is closed.
+func (r *reporter) submit(p reportPayload) {
+	if r == nil || !reportable(p.URL) {
+		return
+	}
+	r.once.Do(func() { go r.run() })
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	select {
+	case r.queue <- p:
+	default:
+	}
+}
+
+// run : Send queued reports until the queue is closed.
+func (r *reporter) run() {
+	defer close(r.done)
+	for p := range r.queue {
+		r.send(p)
+	}
+}
+
+// send : Send a single report.
+func (r *reporter) send(p reportPayload) {
+	rBytes, err := json.Marshal(&p)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewBuffer(rBytes))
+	if err != nil {
+		return
+	}
+	req.Header = r.header.Clone()
+	if resp, err := r.client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+}
+
+// close : Stop accepting reports and wait until the queued ones are sent, or the context is done.
+func (r *reporter) close(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+		r.once.Do(func() { go r.run() })
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close : Send all queued MangaDex@Home reports, after which no more reports are sent.
+func (c *DexClient) Close() error {
+	return c.CloseContext(context.Background())
+}
+
+// CloseContext : Close with custom context, limiting how long to wait for queued reports.
+func (c *DexClient) CloseContext(ctx context.Context) error {
+	return c.reporter.close(ctx)
+}
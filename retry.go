@@ -0,0 +1,84 @@
+package mangodex
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// DefaultRetryPolicy : Reasonable retry policy for use with WithRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+// RetryPolicy : Controls how requests failing with network errors, 429 or 5xx responses are retried.
+// GET and HEAD requests are always retried, POST requests only when RetryPOST is set.
+type RetryPolicy struct {
+	// MaxAttempts : Total number of attempts, including the first one.
+	MaxAttempts int
+	// BaseDelay : Delay before the first retry, doubled for every following retry.
+	BaseDelay time.Duration
+	// MaxDelay : Upper bound for the delay between attempts.
+	MaxDelay time.Duration
+	// RetryPOST : Also retry POST requests. Only enable this if the POST endpoints used are safe to repeat.
+	RetryPOST bool
+}
+
+// WithRetryPolicy : Retry failed requests according to the policy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *DexClient) {
+		c.retry = &policy
+	}
+}
+
+// allows : Check whether requests with this method may be retried.
+func (p *RetryPolicy) allows(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPost:
+		return p.RetryPOST
+	}
+	return false
+}
+
+// backoff : Delay before the given retry attempt (starting from 1), using exponential backoff with full jitter.
+// A longer delay requested by the server through err is respected.
+func (p *RetryPolicy) backoff(attempt int, err error) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	if d > 0 {
+		d = rand.N(d) + 1
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > d {
+		d = apiErr.RetryAfter
+	}
+	return d
+}
+
+// retryable : Check whether a request failing with err may succeed when tried again.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		// Network error.
+		return true
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package mangodex
+
+import (
+	"net/url"
+	"strings"
+)
+
+// routeTemplates : Path templates of the API endpoints used by the services, with the name of each endpoint.
+var routeTemplates = map[string]string{
+	MangaPath:                    "manga.get",
+	MangaAggregatePath:           "manga.aggregate",
+	MangaListPath:                "manga.list",
+	MangaTagPath:                 "manga.tags",
+	CheckIfMangaFollowedPath:     "manga.followed",
+	ToggleMangaFollowPath:        "manga.follow",
+	MangaChapterPath:             "chapter.get",
+	MangaChaptersPath:            "chapter.feed",
+	MangaReadMarkersPath:         "chapter.read_markers",
+	GetUserFollowedMangaListPath: "user.follows",
+	GetLoggedUserPath:            "user.me",
+	LoginPath:                    "auth.login",
+	LogoutPath:                   "auth.logout",
+	RefreshTokenPath:             "auth.refresh",
+	PermissionPath:               "auth.check",
+	GetMDHomeURLPath:             "at_home.server",
+}
+
+// oauthEndpoint : Name of the OAuth2 token endpoint, which is not part of the API.
+const oauthEndpoint = "auth.token"
+
+// matchRoute : Find the path template, such as MangaPath, that matches a path relative to the API base.
+// Templates with more literal segments are preferred. Returns an empty string if nothing matches.
+func matchRoute(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	best, bestLiterals := "", -1
+	for tmpl := range routeTemplates {
+		parts := strings.Split(tmpl, "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+
+		literals := 0
+		for i, part := range parts {
+			if part == "%s" {
+				continue
+			}
+			if part != segments[i] {
+				literals = -1
+				break
+			}
+			literals++
+		}
+		if literals > bestLiterals {
+			best, bestLiterals = tmpl, literals
+		}
+	}
+	return best
+}
+
+// route : Get the path template for a request URL, or an empty string if it is not an API endpoint.
+func (c *DexClient) route(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if u.Host != c.baseURL.Host || !strings.HasPrefix(u.Path, c.baseURL.Path) {
+		return ""
+	}
+	return matchRoute(strings.TrimPrefix(u.Path, c.baseURL.Path))
+}
+
+// routeIDs : Get the segments of a request URL taking the place of the IDs in its path template route.
+func (c *DexClient) routeIDs(rawURL, route string) []string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(u.Path, c.baseURL.Path), "/"), "/")
+
+	var ids []string
+	for i, part := range strings.Split(route, "/") {
+		if part == "%s" && i < len(segments) {
+			ids = append(ids, segments[i])
+		}
+	}
+	return ids
+}
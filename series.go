@@ -0,0 +1,271 @@
+package mangodex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// DefaultChapterConcurrency : Number of chapters a SeriesDownloader downloads at once by default.
+const DefaultChapterConcurrency = 2
+
+// Range : Inclusive range of volume or chapter numbers.
+type Range struct {
+	Min float64
+	Max float64
+}
+
+// contains : Check whether a volume or chapter number is in the range. Missing and non-numeric numbers never are.
+func (r *Range) contains(num *string) bool {
+	if num == nil {
+		return false
+	}
+	f, err := strconv.ParseFloat(*num, 64)
+	return err == nil && f >= r.Min && f <= r.Max
+}
+
+// SeriesEventType : Kind of a SeriesEvent.
+type SeriesEventType int
+
+const (
+	// ChaptersResolved : The chapters to download have been resolved. Total is the number of chapters.
+	ChaptersResolved SeriesEventType = iota
+	// ChapterStarted : A chapter download has started.
+	ChapterStarted
+	// PageDownloaded : A page of a chapter finished, successfully or not. Done and Total count pages of the chapter.
+	PageDownloaded
+	// ChapterFinished : A chapter download finished, successfully or not. Done and Total count chapters.
+	ChapterFinished
+)
+
+func (t SeriesEventType) String() string {
+	switch t {
+	case ChaptersResolved:
+		return "chapters_resolved"
+	case ChapterStarted:
+		return "chapter_started"
+	case PageDownloaded:
+		return "page_downloaded"
+	case ChapterFinished:
+		return "chapter_finished"
+	}
+	return fmt.Sprintf("SeriesEventType(%d)", int(t))
+}
+
+// SeriesEvent : Progress of a SeriesDownloader.
+type SeriesEvent struct {
+	Type    SeriesEventType
+	Chapter *Chapter
+	Page    *PageResult
+	Done    int
+	Total   int
+	Err     error
+}
+
+// ChapterResult : Outcome of downloading a single chapter of a series.
+type ChapterResult struct {
+	Chapter Chapter
+	Dir     string
+	Pages   []PageResult
+	Err     error
+}
+
+// SeriesDownloader : Downloads all chapters of a manga that match its filters.
+// Requests to the API go through the DexClient, so they share its rate limits.
+type SeriesDownloader struct {
+	client *DexClient
+
+	// Languages : Translated languages to download. All languages are downloaded if empty.
+	Languages []string
+	// PreferredGroups : IDs of scanlation groups, most preferred first. When a chapter was uploaded more than once,
+	// the upload by the most preferred group is downloaded, or the most recently published one otherwise.
+	PreferredGroups []string
+	// Volumes : Only download chapters from these volumes, if set.
+	Volumes *Range
+	// Chapters : Only download chapters with these numbers, if set.
+	Chapters *Range
+	// Quality : Quality of the pages, either "data" or "data-saver".
+	Quality string
+	// Concurrency : Maximum number of pages fetched at once, across all chapters.
+	Concurrency int
+	// ChapterConcurrency : Maximum number of chapters downloaded at once.
+	ChapterConcurrency int
+	// OnEvent : Called with the progress of the download. Calls are never concurrent.
+	OnEvent func(SeriesEvent)
+
+	mu sync.Mutex
+}
+
+// NewSeriesDownloader : Create a SeriesDownloader using client.
+func NewSeriesDownloader(client *DexClient) *SeriesDownloader {
+	return &SeriesDownloader{
+		client:             client,
+		Quality:            "data",
+		Concurrency:        DefaultDownloadConcurrency,
+		ChapterConcurrency: DefaultChapterConcurrency,
+	}
+}
+
+// emit : Send an event to OnEvent.
+func (d *SeriesDownloader) emit(e SeriesEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.OnEvent != nil {
+		d.OnEvent(e)
+	}
+}
+
+// Resolve : Get the chapters of a manga to download, with a single upload per chapter number and language.
+func (d *SeriesDownloader) Resolve(ctx context.Context, mangaID string) ([]Chapter, error) {
+	params := &ListChapterParams{
+		Limit:    500,
+		Language: d.Languages,
+		Includes: []string{ScanlationGroupRel},
+	}
+
+	var chapters []Chapter
+	best := map[string]int{}
+	for ch, err := range d.client.Chapter.GetMangaChaptersIter(ctx, mangaID, params) {
+		if err != nil {
+			return nil, err
+		}
+		// External chapters are not hosted on MangaDex@Home.
+		if ch.Attributes.ExternalURL != nil {
+			continue
+		}
+		if d.Volumes != nil && !d.Volumes.contains(ch.Attributes.Volume) {
+			continue
+		}
+		if d.Chapters != nil && !d.Chapters.contains(ch.Attributes.Chapter) {
+			continue
+		}
+
+		// Chapters without a number, such as oneshots, are never duplicates.
+		if ch.Attributes.Chapter == nil {
+			chapters = append(chapters, ch)
+			continue
+		}
+		key := ch.Attributes.TranslatedLanguage + "/" + *ch.Attributes.Chapter
+		if i, ok := best[key]; !ok {
+			best[key] = len(chapters)
+			chapters = append(chapters, ch)
+		} else if d.prefer(&ch, &chapters[i]) {
+			chapters[i] = ch
+		}
+	}
+	SortChapters(chapters)
+	return chapters, nil
+}
+
+// prefer : Check whether upload a of a chapter is preferred over upload b.
+func (d *SeriesDownloader) prefer(a, b *Chapter) bool {
+	if ra, rb := d.groupRank(a), d.groupRank(b); ra != rb {
+		return ra < rb
+	}
+	return a.Attributes.PublishAt > b.Attributes.PublishAt
+}
+
+// groupRank : Get the position of the most preferred scanlation group of a chapter in PreferredGroups.
+func (d *SeriesDownloader) groupRank(ch *Chapter) int {
+	rank := len(d.PreferredGroups)
+	for _, rel := range ch.Relationships {
+		if rel.Type != ScanlationGroupRel {
+			continue
+		}
+		if i := slices.Index(d.PreferredGroups, rel.ID); i >= 0 && i < rank {
+			rank = i
+		}
+	}
+	return rank
+}
+
+// ChapterDir : Get the name of the directory a chapter is downloaded to.
+func ChapterDir(ch *Chapter) string {
+	vol := "none"
+	if v := ch.Attributes.Volume; v != nil {
+		vol = *v
+	}
+	num := ch.ID
+	if c := ch.Attributes.Chapter; c != nil {
+		num = *c
+	}
+	return fmt.Sprintf("%s_vol%s_ch%s", ch.Attributes.TranslatedLanguage, vol, num)
+}
+
+// Download : Download all matching chapters of a manga into subdirectories of dir named with ChapterDir.
+// Chapters already downloaded are resumed rather than fetched again.
+func (d *SeriesDownloader) Download(ctx context.Context, mangaID, dir string) ([]ChapterResult, error) {
+	chapters, err := d.Resolve(ctx, mangaID)
+	if err != nil {
+		return nil, err
+	}
+	d.emit(SeriesEvent{Type: ChaptersResolved, Total: len(chapters)})
+
+	results := make([]ChapterResult, len(chapters))
+	pages := make(chan struct{}, max(d.Concurrency, 1))
+	slots := make(chan struct{}, max(d.ChapterConcurrency, 1))
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+	for i := range chapters {
+		r := &results[i]
+		r.Chapter = chapters[i]
+		r.Dir = filepath.Join(dir, ChapterDir(&r.Chapter))
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			r.Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			d.emit(SeriesEvent{Type: ChapterStarted, Chapter: &r.Chapter})
+			r.Pages, r.Err = d.downloadChapter(ctx, r, pages)
+
+			mu.Lock()
+			done++
+			e := SeriesEvent{Type: ChapterFinished, Chapter: &r.Chapter, Done: done, Total: len(chapters), Err: r.Err}
+			mu.Unlock()
+			d.emit(e)
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("chapter %s (%s): %w", r.Chapter.GetChapterNum(), r.Chapter.ID, r.Err))
+		}
+	}
+	if len(errs) != 0 {
+		return results, fmt.Errorf("%d of %d chapters failed: %w", len(errs), len(chapters), errors.Join(errs...))
+	}
+	return results, nil
+}
+
+// downloadChapter : Download a single chapter, limiting page fetches with sem.
+func (d *SeriesDownloader) downloadChapter(ctx context.Context, r *ChapterResult, sem chan struct{}) ([]PageResult, error) {
+	mc, err := d.client.AtHome.NewMDHomeClientContext(ctx, r.Chapter.ID, d.Quality, false)
+	if err != nil {
+		return nil, err
+	}
+
+	dl := NewDownloader(mc)
+	dl.sem = sem
+	dl.OnProgress = func(p DownloadProgress) {
+		d.emit(SeriesEvent{Type: PageDownloaded, Chapter: &r.Chapter, Page: &p.Page, Done: p.Done, Total: p.Total, Err: p.Page.Err})
+	}
+	return dl.DownloadToDir(ctx, r.Dir)
+}
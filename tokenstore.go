@@ -0,0 +1,197 @@
+package mangodex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// StoredToken : Session state saved by a TokenStore.
+type StoredToken struct {
+	Session   string    `json:"session"`
+	Refresh   string    `json:"refresh"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// OAuth : Whether the tokens were issued by the OAuth2 token endpoint rather than the legacy auth endpoints.
+	OAuth bool `json:"oauth"`
+}
+
+// TokenStore : Persists the tokens of a DexClient, so that a session can be resumed later.
+type TokenStore interface {
+	// Load : Get the saved tokens, or nil if there are none.
+	Load() (*StoredToken, error)
+	// Save : Replace the saved tokens.
+	Save(token *StoredToken) error
+	// Clear : Remove the saved tokens.
+	Clear() error
+}
+
+// WithTokenStore : Save tokens to store whenever the client logs in, refreshes its session or logs out.
+// Use AuthService.ResumeSession to load the saved tokens into a new client.
+func WithTokenStore(store TokenStore) Option {
+	return func(c *DexClient) {
+		c.tokenStore = store
+	}
+}
+
+// ResumeSession : Load tokens from the TokenStore of the client.
+// Returns false if the client has no TokenStore or no tokens were saved.
+func (s *AuthService) ResumeSession() (bool, error) {
+	return s.ResumeSessionContext(context.Background())
+}
+
+// ResumeSessionContext : ResumeSession with custom context. If the saved session token has expired,
+// it is refreshed straight away.
+func (s *AuthService) ResumeSessionContext(ctx context.Context) (bool, error) {
+	if s.client.tokenStore == nil {
+		return false, nil
+	}
+	t, err := s.client.tokenStore.Load()
+	if err != nil || t == nil || t.Refresh == "" {
+		return false, err
+	}
+	s.client.creds.restore(t)
+	s.client.userChanged(ctx)
+
+	if exp, ok := s.client.creds.expiresAt(); ok && time.Now().Add(refreshSkew).After(exp) {
+		if err = s.client.refreshCredentials(ctx); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// persistTokens : Save the current tokens of the client to its TokenStore, if any.
+func (c *DexClient) persistTokens() error {
+	if c.tokenStore == nil {
+		return nil
+	}
+	t := c.creds.snapshot()
+	if t.Session == "" && t.Refresh == "" {
+		return c.tokenStore.Clear()
+	}
+	return c.tokenStore.Save(t)
+}
+
+// saveRefreshedTokens : persistTokens after a refresh. Failures are logged rather than returned, as refreshes
+// usually happen while sending another request, and the new tokens can be used even if they were not saved.
+func (c *DexClient) saveRefreshedTokens(ctx context.Context) {
+	if err := c.persistTokens(); err != nil {
+		c.logTokenStoreError(ctx, err)
+	}
+}
+
+// MemoryTokenStore : TokenStore keeping tokens in memory, for sharing a session between clients of one process.
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	token *StoredToken
+}
+
+// NewMemoryTokenStore : Create an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+func (m *MemoryTokenStore) Load() (*StoredToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.token == nil {
+		return nil, nil
+	}
+	t := *m.token
+	return &t, nil
+}
+
+func (m *MemoryTokenStore) Save(token *StoredToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := *token
+	m.token = &t
+	return nil
+}
+
+func (m *MemoryTokenStore) Clear() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.token = nil
+	return nil
+}
+
+// FileTokenStore : TokenStore saving tokens as JSON to a file only readable by the current user.
+type FileTokenStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileTokenStore : Create a FileTokenStore saving to path.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+func (f *FileTokenStore) Load() (*StoredToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var t StoredToken
+	if err = json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (f *FileTokenStore) Save(token *StoredToken) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(f.path, data, 0o600)
+}
+
+func (f *FileTokenStore) Clear() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// writeFileAtomic : Write data to a temporary file and rename it to path, so readers never see a partial file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err = tmp.Chmod(perm); err == nil {
+		_, err = tmp.Write(data)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
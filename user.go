@@ -3,7 +3,6 @@ package mangodex
 import (
 	"context"
 	"net/http"
-	"net/url"
 	"strconv"
 )
 
@@ -23,8 +22,7 @@ func (s *UserService) GetUserFollowedMangaList(limit, offset int, includes []str
 
 // GetUserFollowedMangaListContext : GetUserFollowedMangaListPath with custom context.
 func (s *UserService) GetUserFollowedMangaListContext(ctx context.Context, limit, offset int, includes []string) (*MangaList, error) {
-	u, _ := url.Parse(BaseAPI)
-	u.Path = GetUserFollowedMangaListPath
+	u := s.client.apiURL(GetUserFollowedMangaListPath)
 
 	// Set required query parameters
 	q := u.Query()
@@ -74,8 +72,7 @@ func (s *UserService) GetLoggedUser() (*UserResponse, error) {
 
 // GetLoggedUserContext : GetLoggedUser with custom context.
 func (s *UserService) GetLoggedUserContext(ctx context.Context) (*UserResponse, error) {
-	u, _ := url.Parse(BaseAPI)
-	u.Path = GetLoggedUserPath
+	u := s.client.apiURL(GetLoggedUserPath)
 
 	var r UserResponse
 	err := s.client.RequestAndDecode(ctx, http.MethodGet, u.String(), nil, &r)
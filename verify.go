@@ -0,0 +1,48 @@
+package mangodex
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// minPageHashLen : Shortest hex prefix in a filename that is treated as a content hash.
+const minPageHashLen = 8
+
+var (
+	// ErrPageHashMismatch : Returned when page data does not match the hash in its filename.
+	ErrPageHashMismatch = errors.New("page data does not match filename hash")
+	// ErrNoPageHash : Returned by VerifyPage when a filename does not contain a hash.
+	ErrNoPageHash = errors.New("filename does not contain a hash")
+)
+
+// pageHash : Get the hex SHA-256 prefix embedded in a MangaDex@Home page filename, such as "x1-<hash>.png".
+func pageHash(filename string) (string, bool) {
+	name := strings.TrimSuffix(path.Base(filename), path.Ext(filename))
+	h := strings.ToLower(name[strings.LastIndex(name, "-")+1:])
+	if len(h) < minPageHashLen || len(h) > sha256.Size*2 || strings.Trim(h, "0123456789abcdef") != "" {
+		return "", false
+	}
+	return h, true
+}
+
+// matchesPageHash : Check a SHA-256 digest against the hash prefix of a filename.
+func matchesPageHash(want string, sum []byte) bool {
+	return strings.HasPrefix(hex.EncodeToString(sum), want)
+}
+
+// VerifyPage : Check page data against the SHA-256 hash embedded in its MangaDex@Home filename.
+func VerifyPage(filename string, data []byte) error {
+	want, ok := pageHash(filename)
+	if !ok {
+		return ErrNoPageHash
+	}
+	sum := sha256.Sum256(data)
+	if !matchesPageHash(want, sum[:]) {
+		return fmt.Errorf("%w: %s", ErrPageHashMismatch, filename)
+	}
+	return nil
+}